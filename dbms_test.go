@@ -39,6 +39,12 @@ func TestQueriesPerDBMS(t *testing.T) {
 		DBMSSQLServer,
 		DBMSMySQL,
 		DBMSSnowflake,
+		DBMSBigQuery,
+		DBMSClickHouse,
+		DBMSSQLite,
+		DBMSTrino,
+		DBMSDB2,
+		DBMSTeradata,
 	}
 
 	for _, dbms := range dbmsTypes {
@@ -100,11 +106,17 @@ func TestQueriesPerDBMS(t *testing.T) {
 								CollectCommands:               true,
 								CollectTables:                 true,
 								CollectProcedure:              true,
+								CollectTableAccess:            false,
+								CollectColumns:                false,
+								CollectAliases:                false,
+								CollectStatements:             false,
+								CollectTableNames:             false,
 								KeepSQLAlias:                  false,
 								UppercaseKeywords:             false,
 								RemoveSpaceBetweenParentheses: false,
 								KeepTrailingSemicolon:         false,
 								KeepIdentifierQuotation:       false,
+								BestEffort:                    false,
 							}
 						}
 
@@ -115,6 +127,17 @@ func TestQueriesPerDBMS(t *testing.T) {
 							WithReplaceBoolean(defaultObfuscatorConfig.ReplaceBoolean),
 							WithReplaceNull(defaultObfuscatorConfig.ReplaceNull),
 							WithKeepJsonPath(defaultObfuscatorConfig.KeepJsonPath),
+							WithStringPlaceholder(defaultObfuscatorConfig.StringPlaceholder),
+							WithNumberPlaceholder(defaultObfuscatorConfig.NumberPlaceholder),
+							WithBooleanPlaceholder(defaultObfuscatorConfig.BooleanPlaceholder),
+							WithNullPlaceholder(defaultObfuscatorConfig.NullPlaceholder),
+							WithDollarQuotedStringPlaceholder(defaultObfuscatorConfig.DollarQuotedStringPlaceholder),
+							WithHexPlaceholder(defaultObfuscatorConfig.HexPlaceholder),
+							WithBinaryPlaceholder(defaultObfuscatorConfig.BinaryPlaceholder),
+							WithNumberedPlaceholders(defaultObfuscatorConfig.NumberedPlaceholders),
+							WithKeepLiteralsInFunctions(defaultObfuscatorConfig.KeepLiteralsInFunctions...),
+							WithKeepLiteralsOfColumns(defaultObfuscatorConfig.KeepLiteralsOfColumns...),
+							WithKeepLiteralsAfterKeywords(defaultObfuscatorConfig.KeepLiteralsAfterKeywords...),
 						)
 
 						normalizer := NewNormalizer(
@@ -122,11 +145,17 @@ func TestQueriesPerDBMS(t *testing.T) {
 							WithCollectCommands(defaultNormalizerConfig.CollectCommands),
 							WithCollectTables(defaultNormalizerConfig.CollectTables),
 							WithCollectProcedures(defaultNormalizerConfig.CollectProcedure),
+							WithCollectTableAccess(defaultNormalizerConfig.CollectTableAccess),
+							WithCollectColumns(defaultNormalizerConfig.CollectColumns),
+							WithCollectAliases(defaultNormalizerConfig.CollectAliases),
+							WithCollectStatements(defaultNormalizerConfig.CollectStatements),
+							WithCollectTableNames(defaultNormalizerConfig.CollectTableNames),
 							WithKeepSQLAlias(defaultNormalizerConfig.KeepSQLAlias),
 							WithUppercaseKeywords(defaultNormalizerConfig.UppercaseKeywords),
 							WithRemoveSpaceBetweenParentheses(defaultNormalizerConfig.RemoveSpaceBetweenParentheses),
 							WithKeepTrailingSemicolon(defaultNormalizerConfig.KeepTrailingSemicolon),
 							WithKeepIdentifierQuotation(defaultNormalizerConfig.KeepIdentifierQuotation),
+							WithBestEffort(defaultNormalizerConfig.BestEffort),
 						)
 
 						got, statementMetadata, err := ObfuscateAndNormalize(string(tt.Input), obfuscator, normalizer, WithDBMS(dbms))
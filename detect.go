@@ -0,0 +1,164 @@
+package sqllexer
+
+import "strings"
+
+// detectionDialect is the entry of detectionRules, which looks the words up in the dictionary of the default dialect.
+var detectionDialect = newDialectEntryWith(detectionRules, nil)
+
+// detectionRules are the lexical rules used to look for the hints of each DBMS.
+// They accept the syntax of every built-in DBMS at once, so that the hints are scanned as tokens
+// rather than swallowed by the rules of a single DBMS, e.g. #temp is an identifier and # comment a comment.
+var detectionRules = Dialect{
+	IdentifierQuotes:      "\"[`",
+	StringQuotes:          `'`,
+	BackslashEscapes:      true,
+	LineCommentPrefixes:   []string{"--", "#"},
+	BindParameterPrefixes: ":@$",
+	IdentifierPrefixes:    "#",
+	DollarQuotedStrings:   true,
+}
+
+// detectableDBMS are the DBMS DetectDBMS can tell apart, the hints of the other ones are too ambiguous.
+var detectableDBMS = []DBMSType{DBMSPostgres, DBMSSQLServer, DBMSMySQL, DBMSOracle, DBMSSnowflake}
+
+const (
+	lexicalHintWeight = 2 // the weight of a lexical hint, e.g. a backtick for MySQL
+	wordHintWeight    = 1 // the weight of a word known to a single DBMS, e.g. ROWNUM for Oracle
+)
+
+// DetectDBMS guesses the DBMS of the query from its lexical hints, and returns it along with a confidence
+// between 0 and 1, the share of the hints that point to it. It returns an empty DBMS and 0 if there is no hint.
+// The hints are the syntax the lexer scans differently from one DBMS to the other:
+//   - backticks and # comments point to MySQL
+//   - [brackets], @@variables and #temp tables point to SQL Server
+//   - :bind parameters and ROWNUM point to Oracle
+//   - $1 parameters and $tag$ strings point to PostgreSQL
+//   - @stages, e.g. in FROM @stage or LIST @stage, and CLONE point to Snowflake
+//
+// The words that are known to a single of these DBMS are hints too, e.g. STRAIGHT_JOIN for MySQL.
+func DetectDBMS(input string) (DBMSType, float64) {
+	lexer := New(input)
+	detection := *detectionDialect
+	detection.dictionary = lexer.dialect.dictionary
+	lexer.dialect = &detection
+
+	scores := make(map[DBMSType]int)
+	var lastToken keywordToken // the last token that is not whitespace or comment
+	var previous keywordToken  // the token right before the current one
+	var stageCommand bool      // whether the statement is a snowflake command on stages, e.g. LIST @stage
+	for {
+		scanned := lexer.Scan()
+		if scanned.Type == EOF {
+			break
+		}
+		token := newKeywordToken(scanned)
+		if lastToken.Value == "" || lastToken.Value == ";" {
+			// the first token of the statement
+			stageCommand = token.Type == IDENT && stageCommands[strings.ToUpper(token.Value)]
+		}
+		detectHints(&token, &lastToken, &previous, stageCommand, scores)
+		previous = token
+		if token.Type != WS && token.Type != COMMENT && token.Type != MULTILINE_COMMENT {
+			lastToken = token
+		}
+	}
+
+	var detected DBMSType
+	best, total := 0, 0
+	for _, dbms := range detectableDBMS {
+		total += scores[dbms]
+		if scores[dbms] > best {
+			detected, best = dbms, scores[dbms]
+		}
+	}
+	if total == 0 {
+		return "", 0
+	}
+	return detected, float64(best) / float64(total)
+}
+
+// stageCommands are the snowflake commands whose arguments are stages, e.g. LIST @stage.
+var stageCommands = map[string]bool{
+	"LIST":   true,
+	"LS":     true,
+	"PUT":    true,
+	"GET":    true,
+	"REMOVE": true,
+	"RM":     true,
+}
+
+// detectHints adds the hints of the token to the scores of the DBMS they point to.
+// stageCommand tells whether the token belongs to a snowflake command on stages, e.g. LIST @stage.
+func detectHints(token *keywordToken, lastToken *keywordToken, previous *keywordToken, stageCommand bool, scores map[DBMSType]int) {
+	afterTableIndicator := commonTableIndicators[lastToken.Keyword]
+	switch token.Type {
+	case QUOTED_IDENT:
+		switch {
+		case strings.HasPrefix(token.Value, "`"):
+			scores[DBMSMySQL] += lexicalHintWeight
+		case strings.HasPrefix(token.Value, "[") && previous.Type != IDENT && previous.Type != QUOTED_IDENT:
+			// a subscript follows the array right away, e.g. postgres tags[1]
+			scores[DBMSSQLServer] += lexicalHintWeight
+		}
+	case COMMENT:
+		if strings.HasPrefix(token.Value, "#") {
+			scores[DBMSMySQL] += lexicalHintWeight
+		}
+	case SYSTEM_VARIABLE:
+		scores[DBMSSQLServer] += lexicalHintWeight
+	case POSITIONAL_PARAMETER, DOLLAR_QUOTED_STRING, DOLLAR_QUOTED_FUNCTION:
+		scores[DBMSPostgres] += lexicalHintWeight
+	case BIND_PARAMETER:
+		switch {
+		case token.Value[0] == ':':
+			scores[DBMSOracle] += lexicalHintWeight
+		case token.Value[0] == '@' && (afterTableIndicator || stageCommand):
+			// e.g. snowflake SELECT * FROM @stage or PUT file:///tmp/data.csv @stage
+			scores[DBMSSnowflake] += lexicalHintWeight
+		case token.Value[0] == '@':
+			scores[DBMSSQLServer] += lexicalHintWeight
+		}
+	case IDENT, FUNCTION:
+		if strings.HasPrefix(token.Value, "#") {
+			if afterTableIndicator {
+				scores[DBMSSQLServer] += lexicalHintWeight
+			} else {
+				// e.g. mysql #comment, with no space after the hash
+				scores[DBMSMySQL] += lexicalHintWeight
+			}
+			return
+		}
+		if token.Keyword == "" {
+			return
+		}
+		if dbms, ok := wordHints[token.Keyword]; ok {
+			scores[dbms] += wordHintWeight
+		}
+	}
+}
+
+// wordHints are the words known to a single of the detectable DBMS, mapped to that DBMS.
+var wordHints = buildWordHints()
+
+func buildWordHints() map[string]DBMSType {
+	known := make(map[string][]DBMSType)
+	for _, dbms := range detectableDBMS {
+		dialect := builtinDialects[dbms]
+		seen := make(map[string]bool)
+		for _, words := range [][]string{dialect.Keywords, dialect.Commands, dialect.TableIndicators} {
+			for _, word := range words {
+				if !seen[word] {
+					seen[word] = true
+					known[word] = append(known[word], dbms)
+				}
+			}
+		}
+	}
+	hints := make(map[string]DBMSType)
+	for word, dbms := range known {
+		if len(dbms) == 1 {
+			hints[word] = dbms[0]
+		}
+	}
+	return hints
+}
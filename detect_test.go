@@ -0,0 +1,130 @@
+package sqllexer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectDBMS(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   DBMSType
+		confidence float64
+	}{
+		{
+			input:      "SELECT * FROM `users` # trailing comment",
+			expected:   DBMSMySQL,
+			confidence: 1,
+		},
+		{
+			input:      "SELECT * FROM users WHERE id = 1 #comment",
+			expected:   DBMSMySQL,
+			confidence: 1,
+		},
+		{
+			input:      "SELECT * FROM [dbo].[users] WHERE id = @id",
+			expected:   DBMSSQLServer,
+			confidence: 1,
+		},
+		{
+			input:      "SELECT @@VERSION",
+			expected:   DBMSSQLServer,
+			confidence: 1,
+		},
+		{
+			input:      "INSERT INTO #temp SELECT * FROM users",
+			expected:   DBMSSQLServer,
+			confidence: 1,
+		},
+		{
+			input:      "SELECT * FROM emp WHERE dept = :dept AND ROWNUM < 10",
+			expected:   DBMSOracle,
+			confidence: 1,
+		},
+		{
+			input:      "SELECT tags[1] FROM posts WHERE id = $1",
+			expected:   DBMSPostgres,
+			confidence: 1,
+		},
+		{
+			input:      "CREATE FUNCTION f() RETURNS int AS $tag$ SELECT 1 $tag$ LANGUAGE sql",
+			expected:   DBMSPostgres,
+			confidence: 1,
+		},
+		{
+			input:      "SELECT t.$1 FROM @my_stage t",
+			expected:   DBMSSnowflake,
+			confidence: 1,
+		},
+		{
+			input:      "LIST @stage",
+			expected:   DBMSSnowflake,
+			confidence: 1,
+		},
+		{
+			input:      "PUT file://x @stage",
+			expected:   DBMSSnowflake,
+			confidence: 1,
+		},
+		{
+			input:      "COPY INTO t FROM @stage",
+			expected:   DBMSSnowflake,
+			confidence: 1,
+		},
+		{
+			// the statements that are not stage commands keep their hints
+			input:      "LIST @a; GET @b file:///tmp/; SELECT * FROM users WHERE id = @id",
+			expected:   DBMSSnowflake,
+			confidence: 2.0 / 3,
+		},
+		{
+			input:      "CREATE TABLE t2 CLONE t1",
+			expected:   DBMSSnowflake,
+			confidence: 1,
+		},
+		{
+			// the MySQL hints outweigh the PostgreSQL one
+			input:      "SELECT * FROM `users` WHERE id = $1 # comment",
+			expected:   DBMSMySQL,
+			confidence: 2.0 / 3,
+		},
+		{
+			input:      "SELECT * FROM users WHERE id = 1",
+			expected:   "",
+			confidence: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			dbms, confidence := DetectDBMS(tt.input)
+			assert.Equal(t, tt.expected, dbms)
+			assert.InDelta(t, tt.confidence, confidence, 0.001)
+		})
+	}
+}
+
+func TestWithDetectDBMS(t *testing.T) {
+	lexer := New("SELECT * FROM #temp", WithDetectDBMS(true))
+	assert.Equal(t, DBMSSQLServer, lexer.config.DBMS)
+	tokens := withoutPositions(lexer.ScanAll())
+	assert.Equal(t, Token{Type: IDENT, Value: "#temp"}, tokens[len(tokens)-1])
+
+	// the DBMS set with WithDBMS wins
+	lexer = New("SELECT * FROM #temp", WithDBMS(DBMSMySQL), WithDetectDBMS(true))
+	assert.Equal(t, DBMSMySQL, lexer.config.DBMS)
+
+	normalizer := NewNormalizer(WithUppercaseKeywords(true), WithCollectTables(true))
+	got, statementMetadata, err := normalizer.Normalize("select top 10 * from [dbo].[users]", WithDetectDBMS(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT TOP 10 * FROM dbo.users", got)
+	assert.Equal(t, []string{"dbo.users"}, statementMetadata.Tables)
+}
+
+func ExampleDetectDBMS() {
+	dbms, confidence := DetectDBMS("SELECT TOP 10 * FROM [dbo].[users] WHERE id = @id")
+	fmt.Println(dbms, confidence)
+	// Output: mssql 1
+}
@@ -0,0 +1,442 @@
+package sqllexer
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Dialect describes the lexical rules of a DBMS.
+// The built-in DBMS are registered dialects, and other DBMS can be supported by registering their own
+// with RegisterDialect, usually starting from a built-in one returned by LookupDialect.
+type Dialect struct {
+	// IdentifierQuotes are the characters that open a quoted identifier, e.g. " and [ for SQL Server.
+	// [ is closed by ], the other quotes are closed by themselves.
+	IdentifierQuotes string
+	// StringQuotes are the characters that open a string literal, e.g. '.
+	StringQuotes string
+	// BackslashEscapes specifies whether a backslash escapes the next character of a string literal, e.g. 'it\'s'.
+	BackslashEscapes bool
+	// TripleQuotedStrings specifies whether three quotes open a string that ends with three quotes,
+	// e.g. BigQuery '''it's'''.
+	TripleQuotedStrings bool
+	// StringPrefixes are the letters that can prefix a string literal, up to two of them,
+	// e.g. r and b for BigQuery raw and bytes strings rb'\d'. Backslashes do not escape in raw strings.
+	StringPrefixes string
+	// BytesPrefixes are the letters of StringPrefixes that make a bytes string, e.g. b for BigQuery b'abc',
+	// which the obfuscator replaces as a string. The other b and B prefixes make a bit string, e.g. Postgres B'0101',
+	// and x and X a hex string.
+	BytesPrefixes string
+	// LineCommentPrefixes are the prefixes of the comments that run until the end of the line, e.g. -- and # for MySQL.
+	LineCommentPrefixes []string
+	// BindParameterPrefixes are the characters that start a bind parameter when followed by a letter or a digit,
+	// e.g. : for Oracle :name.
+	BindParameterPrefixes string
+	// NumberedQuestionMarks specifies whether a question mark followed by a number is a positional parameter,
+	// e.g. SQLite ?1.
+	NumberedQuestionMarks bool
+	// IdentifierPrefixes are the characters that start an identifier when followed by a letter or by themselves,
+	// e.g. # for SQL Server temporary tables, or @ for Snowflake stages.
+	IdentifierPrefixes string
+	// DollarQuotedStrings specifies whether $tag$ ... $tag$ is a string, as in PostgreSQL.
+	// A dollar sign that does not open a tag, e.g. $name, is left to the other rules.
+	DollarQuotedStrings bool
+	// AngleBracketTypes specifies whether ARRAY and STRUCT are followed by their element types between angle brackets,
+	// e.g. BigQuery ARRAY<STRUCT<id INT64>>. The brackets are then scanned as punctuation.
+	AngleBracketTypes bool
+	// BracedParameters specifies whether a name and a type between braces is a query parameter,
+	// e.g. ClickHouse {id:UInt32}. The parameters are scanned as bind parameters.
+	BracedParameters bool
+	// LambdaArrows specifies whether -> is the arrow of a lambda function rather than a JSON operator,
+	// e.g. ClickHouse arrayMap(x -> x + 1, arr). The arrows are then scanned as punctuation.
+	LambdaArrows bool
+	// InsertFormatData specifies whether the FORMAT clause of an INSERT can be followed by the data to insert,
+	// e.g. ClickHouse INSERT INTO t FORMAT JSONEachRow {"id": 1}. The data is scanned as a single FORMAT_DATA token.
+	InsertFormatData bool
+	// BatchSeparator is the word that ends a batch of statements when it stands alone on its line,
+	// e.g. GO for SQL Server or / for Oracle. GO can be followed by a count, e.g. GO 5.
+	BatchSeparator string
+	// PLSQLBlocks specifies whether DECLARE sections and the IS or AS of routines open a block, as in PL/SQL.
+	PLSQLBlocks bool
+	// BeginBlocks specifies whether a BEGIN starting a statement opens a block unless a transaction keyword follows,
+	// e.g. SQL Server BEGIN ... END but BEGIN TRAN. Elsewhere, a bare BEGIN starting a statement starts a transaction.
+	BeginBlocks bool
+	// MaxNameParts is the number of parts of a fully qualified table name,
+	// e.g. 4 for SQL Server server.catalog.schema.name. It defaults to 3.
+	MaxNameParts int
+	// SplitQuotedNames specifies whether the dots inside a quoted identifier separate the parts of a name,
+	// e.g. BigQuery `project.dataset.table`.
+	SplitQuotedNames bool
+	// TerminatorDirectives specifies whether a --#SET TERMINATOR comment changes the statement terminator
+	// of the statements that follow, as in DB2 command line scripts, e.g. --#SET TERMINATOR @.
+	TerminatorDirectives bool
+	// DotCommands specifies whether a line starting with a dot is a command of the client rather than SQL,
+	// e.g. Teradata BTEQ .LOGON. The line is scanned as a single CLIENT_COMMAND token.
+	DotCommands bool
+	// Keywords, Commands and TableIndicators are the words the DBMS gives a meaning to,
+	// in addition to the ones every DBMS shares. See AddToDictionary.
+	Keywords        []string
+	Commands        []string
+	TableIndicators []string
+	// Abbreviations are the abbreviated words mapped to the words they stand for, e.g. Teradata SEL for SELECT.
+	// An abbreviation is collected and recognized as the word it stands for, but is not expanded in the normalized SQL.
+	Abbreviations map[string]string
+}
+
+func (d *Dialect) isIdentifierQuote(ch rune) bool {
+	return ch != 0 && strings.ContainsRune(d.IdentifierQuotes, ch)
+}
+
+func (d *Dialect) isStringQuote(ch rune) bool {
+	return ch != 0 && strings.ContainsRune(d.StringQuotes, ch)
+}
+
+// stringPrefix returns the length of the string prefix the input starts with, or 0.
+// A prefix is only a prefix when it is directly followed by a string quote.
+func (d *Dialect) stringPrefix(input string) int {
+	if d.StringPrefixes == "" {
+		return 0
+	}
+	for i := 0; i < len(input) && i < 3; i++ {
+		if strings.IndexByte(d.StringPrefixes, input[i]) < 0 {
+			if d.isStringQuote(rune(input[i])) {
+				return i
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+func (d *Dialect) isBindParameterPrefix(ch rune) bool {
+	return ch != 0 && strings.ContainsRune(d.BindParameterPrefixes, ch)
+}
+
+func (d *Dialect) isIdentifierPrefix(ch rune) bool {
+	return ch != 0 && strings.ContainsRune(d.IdentifierPrefixes, ch)
+}
+
+// lineCommentPrefix returns the length of the line comment prefix the input starts with, or 0.
+func (d *Dialect) lineCommentPrefix(input string) int {
+	for _, prefix := range d.LineCommentPrefixes {
+		if strings.HasPrefix(input, prefix) {
+			return len(prefix)
+		}
+	}
+	return 0
+}
+
+func (d *Dialect) maxNameParts() int {
+	if d.MaxNameParts <= 0 {
+		return 3
+	}
+	return d.MaxNameParts
+}
+
+// dialectEntry is a registered dialect, along with the dictionary built from its words.
+type dialectEntry struct {
+	Dialect
+	dictionary *dictionary
+	classes    [utf8.RuneSelf]charClass // the classes of the ASCII characters, looked up for every token
+}
+
+// charClass is the set of roles a character has in a dialect, e.g. a string quote.
+type charClass uint8
+
+const (
+	identifierQuoteChar charClass = 1 << iota
+	stringQuoteChar
+	stringPrefixChar
+	bindParameterPrefixChar
+	identifierPrefixChar
+)
+
+// newDialectEntryWith returns the entry of the dialect with the given dictionary, e.g. one updated with more words.
+func newDialectEntryWith(dialect Dialect, dictionary *dictionary) *dialectEntry {
+	entry := &dialectEntry{Dialect: dialect, dictionary: dictionary}
+	for _, chars := range []struct {
+		chars string
+		class charClass
+	}{
+		{dialect.IdentifierQuotes, identifierQuoteChar},
+		{dialect.StringQuotes, stringQuoteChar},
+		{dialect.StringPrefixes, stringPrefixChar},
+		{dialect.BindParameterPrefixes, bindParameterPrefixChar},
+		{dialect.IdentifierPrefixes, identifierPrefixChar},
+	} {
+		for _, ch := range chars.chars {
+			if ch != 0 && ch < utf8.RuneSelf {
+				entry.classes[ch] |= chars.class
+			}
+		}
+	}
+	return entry
+}
+
+// is reports whether the character has the class, looking the ASCII characters up in the table of the dialect.
+// The other characters are looked up in the characters of the class.
+func (d *dialectEntry) is(ch rune, class charClass, chars string) bool {
+	if uint32(ch) < utf8.RuneSelf {
+		return d.classes[ch]&class != 0
+	}
+	return strings.ContainsRune(chars, ch)
+}
+
+func (d *dialectEntry) isIdentifierQuote(ch rune) bool {
+	return d.is(ch, identifierQuoteChar, d.IdentifierQuotes)
+}
+
+func (d *dialectEntry) isStringQuote(ch rune) bool {
+	return d.is(ch, stringQuoteChar, d.StringQuotes)
+}
+
+func (d *dialectEntry) isBindParameterPrefix(ch rune) bool {
+	return d.is(ch, bindParameterPrefixChar, d.BindParameterPrefixes)
+}
+
+func (d *dialectEntry) isIdentifierPrefix(ch rune) bool {
+	return d.is(ch, identifierPrefixChar, d.IdentifierPrefixes)
+}
+
+// stringPrefix is Dialect.stringPrefix, looking the ASCII characters up in the table of the dialect.
+func (d *dialectEntry) stringPrefix(input string) int {
+	for i := 0; i < len(input) && i < 3; i++ {
+		ch := input[i]
+		if ch >= utf8.RuneSelf {
+			return d.Dialect.stringPrefix(input)
+		}
+		if d.classes[ch]&stringPrefixChar == 0 {
+			if d.classes[ch]&stringQuoteChar != 0 {
+				return i
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+var (
+	dialectsMu sync.Mutex // serializes the changes to the dialects and their dictionaries
+	dialects   atomic.Pointer[map[DBMSType]*dialectEntry]
+)
+
+// builtinDialects are the dialects of the DBMS supported out of the box.
+var builtinDialects = map[DBMSType]Dialect{
+	DBMSPostgres: {
+		IdentifierQuotes:      `"`,
+		StringQuotes:          `'`,
+		BackslashEscapes:      true,
+		StringPrefixes:        "xXbB",
+		LineCommentPrefixes:   []string{"--"},
+		BindParameterPrefixes: "@",
+		DollarQuotedStrings:   true,
+		Keywords:              []string{"VACUUM", "ILIKE", "PLPGSQL", "UNLOGGED", "LATERAL"},
+		Commands:              []string{"VACUUM"},
+		TableIndicators:       []string{"ONLY"},
+	},
+	DBMSSQLServer: {
+		IdentifierQuotes:      `"[`,
+		StringQuotes:          `'`,
+		BackslashEscapes:      true,
+		LineCommentPrefixes:   []string{"--"},
+		BindParameterPrefixes: "@",
+		IdentifierPrefixes:    "$#",
+		DollarQuotedStrings:   true,
+		BatchSeparator:        "GO",
+		BeginBlocks:           true,
+		MaxNameParts:          4,
+		Keywords:              []string{"TOP", "OUTPUT", "APPLY"},
+	},
+	DBMSMySQL: {
+		IdentifierQuotes:      "\"`",
+		StringQuotes:          `'`,
+		BackslashEscapes:      true,
+		StringPrefixes:        "xXbB",
+		LineCommentPrefixes:   []string{"--", "#"},
+		BindParameterPrefixes: "@",
+		DollarQuotedStrings:   true,
+		Keywords:              []string{"STRAIGHT_JOIN"},
+		Commands:              []string{"STRAIGHT_JOIN"},
+		TableIndicators:       []string{"STRAIGHT_JOIN"},
+	},
+	DBMSOracle: {
+		IdentifierQuotes:      `"`,
+		StringQuotes:          `'`,
+		BackslashEscapes:      true,
+		LineCommentPrefixes:   []string{"--"},
+		BindParameterPrefixes: ":@",
+		DollarQuotedStrings:   true,
+		BatchSeparator:        "/",
+		PLSQLBlocks:           true,
+		Keywords:              []string{"ROWNUM", "CONNECT", "PRIOR", "NOCYCLE", "MINUS"},
+	},
+	DBMSSnowflake: {
+		IdentifierQuotes:    `"`,
+		StringQuotes:        `'`,
+		BackslashEscapes:    true,
+		LineCommentPrefixes: []string{"--"},
+		IdentifierPrefixes:  "@",
+		DollarQuotedStrings: true,
+		Keywords:            []string{"ILIKE", "CLONE", "CONNECT", "PRIOR", "QUALIFY"},
+		Commands:            []string{"CLONE"},
+		TableIndicators:     []string{"CLONE"},
+	},
+	DBMSBigQuery: {
+		IdentifierQuotes:      "`",
+		StringQuotes:          `'"`,
+		BackslashEscapes:      true,
+		TripleQuotedStrings:   true,
+		StringPrefixes:        "rRbB",
+		BytesPrefixes:         "bB",
+		LineCommentPrefixes:   []string{"--", "#"},
+		BindParameterPrefixes: "@",
+		AngleBracketTypes:     true,
+		SplitQuotedNames:      true,
+		Keywords:              []string{"ARRAY", "STRUCT", "QUALIFY", "UNNEST"},
+	},
+	DBMSSQLite: {
+		IdentifierQuotes:      "\"[`",
+		StringQuotes:          `'`,
+		StringPrefixes:        "xX",
+		LineCommentPrefixes:   []string{"--"},
+		BindParameterPrefixes: ":@$",
+		NumberedQuestionMarks: true,
+		Keywords:              []string{"PRAGMA", "ATTACH", "DETACH", "IGNORE", "GLOB", "AUTOINCREMENT", "VACUUM"},
+		Commands:              []string{"PRAGMA", "ATTACH", "DETACH", "VACUUM"},
+	},
+	DBMSTrino: {
+		IdentifierQuotes:    `"`,
+		StringQuotes:        `'`,
+		LineCommentPrefixes: []string{"--"},
+		Keywords:            []string{"UNNEST", "ORDINALITY", "TABLESAMPLE", "BERNOULLI", "ARRAY", "INTERVAL", "LATERAL", "SHOW", "DESCRIBE"},
+		Commands:            []string{"SHOW", "DESCRIBE"},
+	},
+	DBMSDB2: {
+		IdentifierQuotes:      `"`,
+		StringQuotes:          `'`,
+		LineCommentPrefixes:   []string{"--"},
+		BindParameterPrefixes: ":",
+		TerminatorDirectives:  true,
+		Keywords:              []string{"FIRST", "NEXT", "ROWS", "CALL"},
+		Commands:              []string{"CALL"},
+	},
+	DBMSTeradata: {
+		IdentifierQuotes:      `"`,
+		StringQuotes:          `'`,
+		LineCommentPrefixes:   []string{"--"},
+		BindParameterPrefixes: ":",
+		DotCommands:           true,
+		Keywords:              []string{"QUALIFY", "SAMPLE", "LOCKING", "LOCK", "ACCESS", "TOP"},
+		Abbreviations:         map[string]string{"SEL": "SELECT", "INS": "INSERT", "UPD": "UPDATE", "DEL": "DELETE"},
+	},
+	DBMSClickHouse: {
+		IdentifierQuotes:    "\"`",
+		StringQuotes:        `'`,
+		BackslashEscapes:    true,
+		LineCommentPrefixes: []string{"--", "#"},
+		BracedParameters:    true,
+		LambdaArrows:        true,
+		InsertFormatData:    true,
+		Keywords:            []string{"ARRAY", "FINAL", "FORMAT", "GLOBAL", "PREWHERE", "SETTINGS"},
+	},
+}
+
+func init() {
+	initial := make(map[DBMSType]*dialectEntry)
+	// the default dialect, used when the DBMS is not set or unknown, knows the words of every built-in DBMS,
+	// but not their abbreviations, which are too short to be told apart from the aliases of other DBMS
+	defaultDialect := Dialect{
+		IdentifierQuotes:      `"`,
+		StringQuotes:          `'`,
+		BackslashEscapes:      true,
+		StringPrefixes:        "xXbB",
+		LineCommentPrefixes:   []string{"--"},
+		BindParameterPrefixes: "@$",
+		DollarQuotedStrings:   true,
+	}
+	for _, dbms := range []DBMSType{DBMSPostgres, DBMSSQLServer, DBMSMySQL, DBMSOracle, DBMSSnowflake, DBMSBigQuery, DBMSClickHouse, DBMSSQLite, DBMSTrino, DBMSDB2, DBMSTeradata} {
+		dialect := builtinDialects[dbms]
+		initial[dbms] = newDialectEntry(dialect)
+		defaultDialect.Keywords = append(defaultDialect.Keywords, dialect.Keywords...)
+		defaultDialect.Commands = append(defaultDialect.Commands, dialect.Commands...)
+		defaultDialect.TableIndicators = append(defaultDialect.TableIndicators, dialect.TableIndicators...)
+	}
+	initial[""] = newDialectEntry(defaultDialect)
+	dialects.Store(&initial)
+}
+
+func newDialectEntry(dialect Dialect) *dialectEntry {
+	return newDialectEntryWith(dialect, newDictionary(dialect.Keywords, dialect.Commands, dialect.TableIndicators, dialect.Abbreviations))
+}
+
+// dialectFor returns the dialect of the DBMS, or the default one if the DBMS is unknown.
+func dialectFor(dbms DBMSType) *dialectEntry {
+	all := *dialects.Load()
+	if d, ok := all[dbms]; ok {
+		return d
+	}
+	return all[""]
+}
+
+// storeDialect publishes the dialect of the DBMS, dialectsMu must be held.
+func storeDialect(dbms DBMSType, entry *dialectEntry) {
+	current := *dialects.Load()
+	all := make(map[DBMSType]*dialectEntry, len(current)+1)
+	for name, dialect := range current {
+		all[name] = dialect
+	}
+	all[dbms] = entry
+	dialects.Store(&all)
+}
+
+// RegisterDialect registers the dialect of a DBMS, so that WithDBMS(dbms) lexes with its rules.
+// Registering a DBMS again replaces its dialect, along with the words added with AddToDictionary,
+// so the words are to be added once the dialect is registered.
+// An empty DBMS replaces the default dialect, used when the lexer is not configured with a DBMS.
+// The dialect is copied, changing its slices and maps afterwards does not change the registered one.
+// It is safe to call concurrently with lexing, the lexers already running keep the dialect they started with.
+func RegisterDialect(dbms DBMSType, dialect Dialect) {
+	entry := newDialectEntry(copyDialect(dialect))
+
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	storeDialect(dbms, entry)
+}
+
+// LookupDialect returns the dialect registered for the DBMS, and false if there is none.
+// An empty DBMS returns the default dialect, used when the lexer is not configured with a DBMS.
+// The words added with AddToDictionary are not part of the returned dialect.
+func LookupDialect(dbms DBMSType) (Dialect, bool) {
+	all := *dialects.Load()
+	entry, ok := all[dbms]
+	if !ok {
+		return Dialect{}, false
+	}
+	return copyDialect(entry.Dialect), true
+}
+
+// copyDialect returns a copy of the dialect that shares none of its slices and maps.
+func copyDialect(dialect Dialect) Dialect {
+	dialect.LineCommentPrefixes = copyStrings(dialect.LineCommentPrefixes)
+	dialect.Keywords = copyStrings(dialect.Keywords)
+	dialect.Commands = copyStrings(dialect.Commands)
+	dialect.TableIndicators = copyStrings(dialect.TableIndicators)
+	dialect.Abbreviations = copyAbbreviations(dialect.Abbreviations)
+	return dialect
+}
+
+func copyStrings(values []string) []string {
+	return append([]string(nil), values...)
+}
+
+func copyAbbreviations(abbreviations map[string]string) map[string]string {
+	if abbreviations == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(abbreviations))
+	for abbreviation, word := range abbreviations {
+		copied[abbreviation] = word
+	}
+	return copied
+}
@@ -0,0 +1,129 @@
+package sqllexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterDialect(t *testing.T) {
+	const dbms DBMSType = "dialect-test"
+	RegisterDialect(dbms, Dialect{
+		IdentifierQuotes:      "`",
+		StringQuotes:          `'"`,
+		LineCommentPrefixes:   []string{"--", "//"},
+		BindParameterPrefixes: ":",
+		BatchSeparator:        "GO",
+		Keywords:              []string{"SAMPLE"},
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []Token
+	}{
+		{
+			name:  "quotes and comments",
+			input: "SELECT `a b`, \"str\" // comment\nFROM t",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "`a b`"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: `"str"`},
+				{Type: WS, Value: " "},
+				{Type: COMMENT, Value: "// comment"},
+				{Type: WS, Value: "\n"},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "t"},
+			},
+		},
+		{
+			name:  "no backslash escapes",
+			input: `SELECT 'C:\' FROM t`,
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: `'C:\'`},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "t"},
+			},
+		},
+		{
+			name:  "parameters",
+			input: "SELECT :id, @id, $1",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: ":id"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "@"},
+				{Type: IDENT, Value: "id"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: POSITIONAL_PARAMETER, Value: "$1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := New(tt.input, WithDBMS(dbms))
+			assert.Equal(t, tt.expected, withoutPositions(lexer.ScanAll()))
+		})
+	}
+
+	normalizer := NewNormalizer(WithUppercaseKeywords(true), WithCollectTableNames(true))
+	got, statementMetadata, err := normalizer.Normalize("select * from a.b.c sample 10", WithDBMS(dbms))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM a.b.c SAMPLE 10", got)
+	assert.Equal(t, []TableName{{Catalog: "a", Schema: "b", Name: "c", Original: "a.b.c"}}, statementMetadata.TableNames)
+
+	statements := SplitStatements("SELECT 1\nGO\nSELECT 2", WithDBMS(dbms))
+	assert.Len(t, statements, 2)
+}
+
+func TestLookupDialect(t *testing.T) {
+	postgres, ok := LookupDialect(DBMSPostgres)
+	assert.True(t, ok)
+	assert.True(t, postgres.DollarQuotedStrings)
+
+	// a dialect can start from a built-in one, without changing it
+	postgres.Keywords = append(postgres.Keywords, "UPSERT")
+	RegisterDialect("dialect-lookup-test", postgres)
+
+	normalizer := NewNormalizer(WithUppercaseKeywords(true))
+	got, _, err := normalizer.Normalize("upsert into users", WithDBMS("dialect-lookup-test"))
+	assert.NoError(t, err)
+	assert.Equal(t, "UPSERT INTO users", got)
+	got, _, err = normalizer.Normalize("upsert into users", WithDBMS(DBMSPostgres))
+	assert.NoError(t, err)
+	assert.Equal(t, "upsert INTO users", got)
+
+	_, ok = LookupDialect("dialect-unknown")
+	assert.False(t, ok)
+
+	// the registered dialect does not share its slices with the one it was registered with
+	keywords := []string{"SAMPLE", "UPSERT"}
+	RegisterDialect("dialect-copy-test", Dialect{StringQuotes: `'`, Keywords: keywords})
+	keywords[1] = "BUCKET"
+	registered, ok := LookupDialect("dialect-copy-test")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"SAMPLE", "UPSERT"}, registered.Keywords)
+
+	// the default dialect is the one of the empty DBMS, for both RegisterDialect and LookupDialect
+	defaultDialect, ok := LookupDialect("")
+	assert.True(t, ok)
+	assert.True(t, defaultDialect.DollarQuotedStrings)
+	defer RegisterDialect("", defaultDialect)
+	RegisterDialect("", Dialect{StringQuotes: `'`, LineCommentPrefixes: []string{"//"}})
+	replaced, ok := LookupDialect("")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"//"}, replaced.LineCommentPrefixes)
+	assert.Equal(t, COMMENT, New("// comment").Scan().Type)
+}
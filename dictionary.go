@@ -0,0 +1,478 @@
+package sqllexer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DictionaryKind is a kind of words a DBMS gives a meaning to.
+type DictionaryKind int
+
+const (
+	// Keywords are the SQL keywords, uppercased by the normalizer when UppercaseKeywords is set
+	Keywords DictionaryKind = iota
+	// Commands are the statements collected as metadata, e.g. SELECT or GRANT
+	Commands
+	// TableIndicators are the words followed by a table name, e.g. FROM or JOIN
+	TableIndicators
+)
+
+// KeywordID identifies a keyword, i.e. a word of one of the dictionaries, e.g. SELECT or the Snowflake QUALIFY.
+// A keyword has the same ID whatever the DBMS, and the zero KeywordID is no keyword.
+// The IDs are assigned as the words are added to the dictionaries, so they can differ from one process to the next:
+// compare them with the ones returned by LookupKeyword rather than with constants.
+type KeywordID int32
+
+// String returns the uppercase keyword, e.g. SELECT, or an empty string for the zero KeywordID.
+func (k KeywordID) String() string {
+	names := *keywordNames.Load()
+	if k <= 0 || int(k) >= len(names) {
+		return ""
+	}
+	return names[k]
+}
+
+// LookupKeyword returns the ID of the keyword, and false if it is not a word of any of the dictionaries, e.g.
+// LookupKeyword("select"). The values that read like words, e.g. NULL or CURRENT_DATE, are not keywords.
+func LookupKeyword(word string) (KeywordID, bool) {
+	keywordsMu.Lock()
+	defer keywordsMu.Unlock()
+	id, ok := keywordIDs[strings.ToUpper(word)]
+	return id, ok
+}
+
+var (
+	keywordsMu   sync.Mutex // serializes the assignment of the keyword IDs
+	keywordIDs   = map[string]KeywordID{}
+	keywordNames = newKeywordNames() // the keywords indexed by their ID, only ever appended to
+)
+
+// newKeywordNames returns the names of the keywords with the zero KeywordID only.
+// They are set up along with the variables rather than in an init function, which could run after the dialects are registered.
+func newKeywordNames() *atomic.Pointer[[]string] {
+	names := &atomic.Pointer[[]string]{}
+	names.Store(&[]string{""})
+	return names
+}
+
+// assignKeywordIDs returns the IDs of the keywords, assigning new ones to the keywords not seen before.
+func assignKeywordIDs(words []string) []KeywordID {
+	keywordsMu.Lock()
+	defer keywordsMu.Unlock()
+	names := *keywordNames.Load()
+	ids := make([]KeywordID, len(words))
+	for i, word := range words {
+		id, ok := keywordIDs[word]
+		if !ok {
+			// the names are copied rather than appended to in place, as lexers may be reading them
+			names = append(names[:len(names):len(names)], word)
+			id = KeywordID(len(names) - 1)
+			keywordIDs[word] = id
+		}
+		ids[i] = id
+	}
+	keywordNames.Store(&names)
+	return ids
+}
+
+// dictionary holds the words a DBMS gives a meaning to.
+// A dictionary is never modified once published, changes are made on a copy.
+type dictionary struct {
+	keywords        map[string]bool
+	commands        map[string]bool
+	tableIndicators map[string]bool
+	abbreviations   map[string]string    // the abbreviations mapped to the words they stand for, e.g. SEL to SELECT
+	ids             map[string]KeywordID // the keywords and the abbreviations mapped to the IDs of the words, see lookupKeywordID
+	maxLength       int                  // the length of the longest keyword or abbreviation
+}
+
+// words is the set of words the library relies on besides the keywords of the dictionaries,
+// e.g. the values, which are not keywords but not columns either, see word.
+var words = newWordSet(aliasStopWords, otherKeywords, valueWords)
+
+// wordSet is a set of uppercase words that identifiers are looked up in regardless of case.
+type wordSet struct {
+	words     map[string]string // the words mapped to themselves
+	maxLength int               // the length of the longest word
+}
+
+func newWordSet(sets ...map[string]bool) *wordSet {
+	w := &wordSet{words: make(map[string]string)}
+	for _, set := range sets {
+		for word := range set {
+			w.words[word] = word
+			w.maxLength = maxInt(w.maxLength, len(word))
+		}
+	}
+	return w
+}
+
+// commonCommands, commonTableIndicators and commonKeywords are the words every DBMS gives a meaning to
+var commonCommands = map[string]bool{
+	"SELECT":   true,
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"CREATE":   true,
+	"ALTER":    true,
+	"DROP":     true,
+	"JOIN":     true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"COMMIT":   true,
+	"BEGIN":    true,
+	"TRUNCATE": true,
+	"MERGE":    true,
+	"EXECUTE":  true,
+	"EXEC":     true,
+	"EXPLAIN":  true,
+	"USE":      true,
+}
+
+var commonTableIndicators = map[string]bool{
+	"FROM":   true,
+	"JOIN":   true,
+	"INTO":   true,
+	"UPDATE": true,
+	"TABLE":  true,
+	"EXISTS": true, // Drop Table If Exists
+}
+
+var commonKeywords = map[string]bool{
+	"SELECT":     true,
+	"INSERT":     true,
+	"UPDATE":     true,
+	"DELETE":     true,
+	"CREATE":     true,
+	"ALTER":      true,
+	"DROP":       true,
+	"GRANT":      true,
+	"REVOKE":     true,
+	"ADD":        true,
+	"ALL":        true,
+	"AND":        true,
+	"ANY":        true,
+	"AS":         true,
+	"ASC":        true,
+	"BEGIN":      true,
+	"BETWEEN":    true,
+	"BY":         true,
+	"CASE":       true,
+	"CHECK":      true,
+	"COLUMN":     true,
+	"COMMIT":     true,
+	"CONSTRAINT": true,
+	"DATABASE":   true,
+	"DECLARE":    true,
+	"DEFAULT":    true,
+	"DESC":       true,
+	"DISTINCT":   true,
+	"ELSE":       true,
+	"END":        true,
+	"EXEC":       true,
+	"EXISTS":     true,
+	"FOREIGN":    true,
+	"FROM":       true,
+	"GROUP":      true,
+	"HAVING":     true,
+	"IN":         true,
+	"INDEX":      true,
+	"INNER":      true,
+	"INTO":       true,
+	"IS":         true,
+	"JOIN":       true,
+	"KEY":        true,
+	"LEFT":       true,
+	"LIKE":       true,
+	"LIMIT":      true,
+	"NOT":        true,
+	"ON":         true,
+	"OR":         true,
+	"ORDER":      true,
+	"OUTER":      true,
+	"PRIMARY":    true,
+	"PROCEDURE":  true,
+	"REPLACE":    true,
+	"RETURNS":    true,
+	"RIGHT":      true,
+	"ROLLBACK":   true,
+	"SET":        true,
+	"SOME":       true,
+	"TABLE":      true,
+	"TRUNCATE":   true,
+	"UNION":      true,
+	"UNIQUE":     true,
+	"USE":        true,
+	"VALUES":     true,
+	"VIEW":       true,
+	"WHERE":      true,
+	"CUBE":       true,
+	"ROLLUP":     true,
+	"LITERAL":    true,
+	"WINDOW":     true,
+	"ANALYZE":    true,
+	"USING":      true,
+	"ASSERTION":  true,
+	"DOMAIN":     true,
+	"CLUSTER":    true,
+	"COPY":       true,
+	"EXPLAIN":    true,
+	"TRIGGER":    true,
+	"TEMPORARY":  true,
+	"RECURSIVE":  true,
+	"RETURNING":  true,
+	"OFFSET":     true,
+	"OF":         true,
+	"SKIP":       true,
+	"IF":         true,
+	"ONLY":       true,
+}
+
+// otherKeywords are the words the library relies on that are not part of the maps above
+var otherKeywords = map[string]bool{
+	"PROC":        true,
+	"FUNCTION":    true,
+	"PACKAGE":     true,
+	"BODY":        true,
+	"TYPE":        true,
+	"GO":          true,
+	"INTERSECT":   true,
+	"EXCEPT":      true,
+	"FETCH":       true,
+	"LOOP":        true,
+	"WHILE":       true,
+	"REPEAT":      true,
+	"FOR":         true,
+	"TRANSACTION": true,
+	"TRAN":        true,
+	"WORK":        true,
+	"DISTRIBUTED": true,
+	"ISOLATION":   true,
+	"READ":        true,
+	"DEFERRED":    true,
+	"IMMEDIATE":   true,
+	"EXCLUSIVE":   true,
+	"NULLS":       true,
+	"MATCHED":     true,
+}
+
+// valueWords are the values that read like words, e.g. NULL, or like columns without being one, e.g. CURRENT_DATE.
+// They are not keywords.
+var valueWords = map[string]bool{
+	"TRUE":              true,
+	"FALSE":             true,
+	"NULL":              true,
+	"CURRENT_DATE":      true,
+	"CURRENT_TIME":      true,
+	"CURRENT_TIMESTAMP": true,
+	"CURRENT_USER":      true,
+	"SESSION_USER":      true,
+	"SYSTEM_USER":       true,
+	"LOCALTIME":         true,
+	"LOCALTIMESTAMP":    true,
+	"SYSDATE":           true,
+	"SYSTIMESTAMP":      true,
+	"ROWNUM":            true,
+	"ROWID":             true,
+}
+
+// fromArgumentFunctions are the functions whose arguments are separated by FROM, e.g. EXTRACT(YEAR FROM ts)
+var fromArgumentFunctions = map[string]bool{
+	"EXTRACT":   true,
+	"SUBSTRING": true,
+	"TRIM":      true,
+	"OVERLAY":   true,
+}
+
+// maxKeywordLength is the size of the buffer used to uppercase identifiers before looking them up,
+// longer identifiers are uppercased with an allocation, which only happens for unusually long keywords
+const maxKeywordLength = 32
+
+// newDictionary returns a dictionary with the common words and the given extra ones.
+func newDictionary(keywords, commands, tableIndicators []string, abbreviations map[string]string) *dictionary {
+	d := &dictionary{
+		keywords:        copyWords(commonKeywords),
+		commands:        copyWords(commonCommands),
+		tableIndicators: copyWords(commonTableIndicators),
+		abbreviations:   make(map[string]string, len(abbreviations)),
+	}
+	for abbreviation, word := range abbreviations {
+		d.abbreviations[strings.ToUpper(abbreviation)] = strings.ToUpper(word)
+	}
+	for _, word := range keywords {
+		d.keywords[word] = true
+	}
+	for _, word := range commands {
+		d.commands[word] = true
+	}
+	for _, word := range tableIndicators {
+		d.tableIndicators[word] = true
+	}
+	d.buildNames()
+	return d
+}
+
+func copyWords(words map[string]bool) map[string]bool {
+	copied := make(map[string]bool, len(words))
+	for word := range words {
+		copied[word] = true
+	}
+	return copied
+}
+
+// buildNames indexes the keywords the lexer assigns an ID to, and the abbreviations of keywords.
+func (d *dictionary) buildNames() {
+	var names, keywords []string
+	for _, words := range []map[string]bool{d.keywords, d.commands, d.tableIndicators} {
+		for word := range words {
+			names = append(names, word)
+			keywords = append(keywords, word)
+		}
+	}
+	for abbreviation, word := range d.abbreviations {
+		// the abbreviations are looked up as the words they stand for
+		names = append(names, abbreviation)
+		keywords = append(keywords, word)
+	}
+	d.ids = make(map[string]KeywordID, len(names))
+	d.maxLength = 0
+	for i, id := range assignKeywordIDs(keywords) {
+		d.ids[names[i]] = id
+		d.maxLength = maxInt(d.maxLength, len(names[i]))
+	}
+}
+
+// words returns the words of the given kind.
+func (d *dictionary) words(kind DictionaryKind) map[string]bool {
+	switch kind {
+	case Commands:
+		return d.commands
+	case TableIndicators:
+		return d.tableIndicators
+	default:
+		return d.keywords
+	}
+}
+
+// AddToDictionary adds words of the given kind to the dictionary of the DBMS, e.g.
+// AddToDictionary(DBMSSnowflake, Keywords, "MATCH_RECOGNIZE") makes the normalizer uppercase MATCH_RECOGNIZE
+// in Snowflake queries only. Words are case insensitive and must be made of ASCII letters, digits and underscores.
+// An empty DBMS adds the words to the default dictionary, used when the lexer is not configured with a DBMS.
+// The dialect of any other DBMS must be registered first, as RegisterDialect drops the words added before,
+// and ErrUnknownDBMS is returned for a DBMS that is not registered, without changing any dictionary.
+// It is safe to call concurrently with lexing, the lexers and normalizers already running keep the words they started with.
+func AddToDictionary(dbms DBMSType, kind DictionaryKind, words ...string) error {
+	return updateDictionary(dbms, kind, words, true)
+}
+
+// RemoveFromDictionary removes words of the given kind from the dictionary of the DBMS,
+// e.g. RemoveFromDictionary(DBMSMySQL, TableIndicators, "TABLE").
+// It follows the same rules as AddToDictionary.
+func RemoveFromDictionary(dbms DBMSType, kind DictionaryKind, words ...string) error {
+	return updateDictionary(dbms, kind, words, false)
+}
+
+// ErrUnknownDBMS is returned when changing the dictionary of a DBMS whose dialect is not registered.
+var ErrUnknownDBMS = errors.New("unknown DBMS")
+
+func updateDictionary(dbms DBMSType, kind DictionaryKind, words []string, add bool) error {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	entry, ok := (*dialects.Load())[dbms]
+	if !ok {
+		// the words would go to a dictionary of its own, which RegisterDialect would then replace
+		return fmt.Errorf("%w %q, its dialect must be registered with RegisterDialect first", ErrUnknownDBMS, dbms)
+	}
+	d := entry.dictionary
+	updated := &dictionary{
+		keywords:        copyWords(d.keywords),
+		commands:        copyWords(d.commands),
+		tableIndicators: copyWords(d.tableIndicators),
+		abbreviations:   d.abbreviations,
+	}
+	target := updated.words(kind)
+	for _, word := range words {
+		if add {
+			target[strings.ToUpper(word)] = true
+		} else {
+			delete(target, strings.ToUpper(word))
+		}
+	}
+	updated.buildNames()
+
+	storeDialect(dbms, newDialectEntryWith(entry.Dialect, updated))
+	return nil
+}
+
+// lookupKeywordID returns the ID of the keyword the identifier is, or stands for if it is an abbreviation,
+// or 0 if it is not a keyword.
+func (d *dictionary) lookupKeywordID(ident string) KeywordID {
+	return lookupWord(d.ids, d.maxLength, ident)
+}
+
+// lookupWord returns the value of the identifier in the map of uppercase words if it is one of them,
+// or the zero value otherwise. The lookup does not allocate.
+func lookupWord[V any](words map[string]V, maxLength int, ident string) V {
+	var none V
+	if len(ident) > maxLength {
+		return none
+	}
+	if len(ident) > maxKeywordLength {
+		return words[strings.ToUpper(ident)]
+	}
+	var buf [maxKeywordLength]byte
+	for i := 0; i < len(ident); i++ {
+		ch := ident[i]
+		switch {
+		case 'a' <= ch && ch <= 'z':
+			buf[i] = ch - ('a' - 'A')
+		case 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9', ch == '_':
+			buf[i] = ch
+		default:
+			return none
+		}
+	}
+	return words[string(buf[:len(ident)])]
+}
+
+// word returns the word the token stands for if it is an IDENT or a FUNCTION that is either a keyword,
+// see Token.KeywordID, or one of the other words the library relies on, e.g. NULL, and an empty string otherwise.
+func word(token *Token) string {
+	if token.KeywordID != 0 {
+		return token.KeywordID.String()
+	}
+	if token.Type != IDENT && token.Type != FUNCTION {
+		return ""
+	}
+	return lookupWord(words.words, words.maxLength, token.Value)
+}
+
+// keywordToken is a token along with its word, for the code that checks the word of a token many times.
+type keywordToken struct {
+	Token
+	Keyword string // see word
+}
+
+// newKeywordToken returns the token along with its word.
+func newKeywordToken(token Token) keywordToken {
+	return keywordToken{Token: token, Keyword: word(&token)}
+}
+
+func (d *dictionary) isKeyword(token *keywordToken) bool {
+	if token.Type != IDENT {
+		return false
+	}
+	return d.keywords[token.Keyword]
+}
+
+func (d *dictionary) isCommand(ident string) bool {
+	return d.commands[ident]
+}
+
+func (d *dictionary) isTableIndicator(ident string) bool {
+	return d.tableIndicators[ident]
+}
@@ -0,0 +1,201 @@
+package sqllexer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDictionaryPerDBMS(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		commands  []string
+		tables    []string
+		lexerOpts []lexerOption
+	}{
+		{
+			input:    "vacuum analyze users",
+			expected: "VACUUM ANALYZE users",
+			commands: []string{"VACUUM"},
+			tables:   []string{},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSPostgres),
+			},
+		},
+		{
+			input:    "vacuum analyze users",
+			expected: "vacuum ANALYZE users",
+			commands: []string{},
+			tables:   []string{},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSMySQL),
+			},
+		},
+		{
+			input:    "select * from only users",
+			expected: "SELECT * FROM ONLY users",
+			commands: []string{"SELECT"},
+			tables:   []string{"users"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSPostgres),
+			},
+		},
+		{
+			input:    "select * from a straight_join b on a.id = b.id",
+			expected: "SELECT * FROM a STRAIGHT_JOIN b ON a.id = b.id",
+			commands: []string{"SELECT", "STRAIGHT_JOIN"},
+			tables:   []string{"a", "b"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSMySQL),
+			},
+		},
+		{
+			input:    "select top 10 * from users",
+			expected: "SELECT TOP ? * FROM users",
+			commands: []string{"SELECT"},
+			tables:   []string{"users"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+			},
+		},
+		{
+			input:    "select rownum, top from users",
+			expected: "SELECT rownum, top FROM users",
+			commands: []string{"SELECT"},
+			tables:   []string{"users"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSPostgres),
+			},
+		},
+		{
+			input:    "select id from emp where rownum < 10 connect by prior id = manager_id",
+			expected: "SELECT id FROM emp WHERE ROWNUM < ? CONNECT BY PRIOR id = manager_id",
+			commands: []string{"SELECT"},
+			tables:   []string{"emp"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSOracle),
+			},
+		},
+		{
+			input:    "create table t2 clone t1",
+			expected: "CREATE TABLE t2 CLONE t1",
+			commands: []string{"CREATE", "CLONE"},
+			tables:   []string{"t2", "t1"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSnowflake),
+			},
+		},
+		{
+			input:    "select * from a cross join unnest(a.items) with ordinality",
+			expected: "SELECT * FROM a cross JOIN unnest ( a.items ) with ORDINALITY",
+			commands: []string{"SELECT", "JOIN"},
+			tables:   []string{"a"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSTrino),
+			},
+		},
+	}
+
+	obfuscator := NewObfuscator()
+	normalizer := NewNormalizer(
+		WithCollectCommands(true),
+		WithCollectTables(true),
+		WithUppercaseKeywords(true),
+	)
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, statementMetadata, err := ObfuscateAndNormalize(test.input, obfuscator, normalizer, test.lexerOpts...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+			assert.Equal(t, test.commands, statementMetadata.Commands)
+			assert.Equal(t, test.tables, statementMetadata.Tables)
+		})
+	}
+}
+
+func TestAddToDictionary(t *testing.T) {
+	// use a DBMS of its own so that the other tests are not affected
+	const dbms DBMSType = "dictionary-test"
+
+	// the words of a DBMS that is not registered go nowhere
+	assert.ErrorIs(t, AddToDictionary(dbms, Keywords, "UPSERT"), ErrUnknownDBMS)
+	assert.ErrorIs(t, RemoveFromDictionary(dbms, Keywords, "SELECT"), ErrUnknownDBMS)
+	_, ok := LookupDialect(dbms)
+	assert.False(t, ok)
+
+	RegisterDialect(dbms, Dialect{StringQuotes: `'`})
+
+	normalizer := NewNormalizer(
+		WithCollectCommands(true),
+		WithCollectTables(true),
+		WithUppercaseKeywords(true),
+	)
+	normalize := func(input string) (string, *StatementMetadata) {
+		got, statementMetadata, err := normalizer.Normalize(input, WithDBMS(dbms))
+		assert.NoError(t, err)
+		return got, statementMetadata
+	}
+
+	got, statementMetadata := normalize("upsert into users select * from staging bucket (10)")
+	assert.Equal(t, "upsert INTO users SELECT * FROM staging bucket ( 10 )", got)
+	assert.Equal(t, []string{"SELECT"}, statementMetadata.Commands)
+
+	assert.NoError(t, AddToDictionary(dbms, Keywords, "bucket", "UPSERT"))
+	assert.NoError(t, AddToDictionary(dbms, Commands, "Upsert"))
+	got, statementMetadata = normalize("upsert into users select * from staging bucket (10)")
+	assert.Equal(t, "UPSERT INTO users SELECT * FROM staging BUCKET ( 10 )", got)
+	assert.Equal(t, []string{"UPSERT", "SELECT"}, statementMetadata.Commands)
+	assert.Equal(t, []string{"users", "staging"}, statementMetadata.Tables)
+
+	// the words added are keywords the lexer identifies the tokens with
+	bucketID, ok := LookupKeyword("BUCKET")
+	assert.True(t, ok)
+	assert.Equal(t, bucketID, New("bucket", WithDBMS(dbms)).Scan().KeywordID)
+	assert.Zero(t, New("bucket", WithDBMS(DBMSPostgres)).Scan().KeywordID)
+
+	assert.NoError(t, RemoveFromDictionary(dbms, TableIndicators, "INTO"))
+	assert.NoError(t, RemoveFromDictionary(dbms, Keywords, "bucket"))
+	got, statementMetadata = normalize("upsert into users select * from staging bucket (10)")
+	assert.Equal(t, "UPSERT INTO users SELECT * FROM staging bucket ( 10 )", got)
+	assert.Equal(t, []string{"staging"}, statementMetadata.Tables)
+	assert.Zero(t, New("bucket", WithDBMS(dbms)).Scan().KeywordID)
+
+	// registering the dialect again drops the words added to it
+	assert.NoError(t, AddToDictionary(dbms, Keywords, "UPSERT"))
+	RegisterDialect(dbms, Dialect{StringQuotes: `'`})
+	got, _ = normalize("upsert into users")
+	assert.Equal(t, "upsert INTO users", got)
+
+	// the other DBMS are not affected
+	got, _, err := normalizer.Normalize("upsert into users", WithDBMS(DBMSPostgres))
+	assert.NoError(t, err)
+	assert.Equal(t, "upsert INTO users", got)
+}
+
+func TestAddToDictionaryConcurrently(t *testing.T) {
+	const dbms DBMSType = "dictionary-concurrency-test"
+	RegisterDialect(dbms, Dialect{StringQuotes: `'`})
+
+	normalizer := NewNormalizer(WithUppercaseKeywords(true))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				got, _, err := normalizer.Normalize("select * from users", WithDBMS(dbms))
+				assert.NoError(t, err)
+				assert.Equal(t, "SELECT * FROM users", got)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		AddToDictionary(dbms, Keywords, "QUALIFY")
+		RemoveFromDictionary(dbms, Keywords, "QUALIFY")
+	}
+	wg.Wait()
+}
@@ -1,7 +1,9 @@
 package sqllexer
 
 import (
+	"io"
 	"strings"
+	"sync"
 )
 
 type normalizerConfig struct {
@@ -17,6 +19,29 @@ type normalizerConfig struct {
 	// CollectProcedure specifies whether the normalizer should extract and return procedure name as SQL metadata
 	CollectProcedure bool `json:"collect_procedure"`
 
+	// CollectTableAccess specifies whether the normalizer should also extract how each table is accessed,
+	// e.g. read by a SELECT or written by an INSERT, along with the command that accesses it
+	CollectTableAccess bool `json:"collect_table_access"`
+
+	// CollectColumns specifies whether the normalizer should also extract the column names that a query addresses,
+	// e.g. in the SELECT list, WHERE and ON predicates, INSERT column lists, UPDATE SET targets and ORDER/GROUP BY.
+	// Qualified column names are resolved against the table aliases, e.g. u.id becomes users.id.
+	CollectColumns bool `json:"collect_columns"`
+
+	// CollectAliases specifies whether the normalizer should also extract the table aliases,
+	// both explicit (FROM users AS u) and implicit (FROM users u), even when KeepSQLAlias is off.
+	CollectAliases bool `json:"collect_aliases"`
+
+	// CollectStatements specifies whether the normalizer should also return the metadata of each statement
+	// of a multi-statement input separately, e.g. to tell which statement a table belongs to.
+	// The commands, tables, comments, procedures and table accesses of each statement are collected even if
+	// the options collecting them for the whole input are off.
+	CollectStatements bool `json:"collect_statements"`
+
+	// CollectTableNames specifies whether the normalizer should also extract the table names split into their parts,
+	// e.g. the catalog, schema and name of db.dbo.users, following the naming rules of the DBMS.
+	CollectTableNames bool `json:"collect_table_names"`
+
 	// KeepSQLAlias specifies whether SQL aliases ("AS") should be truncated.
 	KeepSQLAlias bool `json:"keep_sql_alias"`
 
@@ -29,15 +54,32 @@ type normalizerConfig struct {
 
 	// KeepTrailingSemicolon specifies whether the normalizer should keep the trailing semicolon.
 	// The trailing semicolon is removed by default, but this can be disabled by setting this to true.
+	// In DB2 scripts, it is the terminator set by the last --#SET TERMINATOR directive that is removed.
 	// PL/SQL requires a trailing semicolon, so this should be set to true when normalizing PL/SQL.
 	KeepTrailingSemicolon bool `json:"keep_trailing_semicolon"`
 
 	// KeepIdentifierQuotation specifies whether the normalizer should keep the quotation of identifiers.
 	KeepIdentifierQuotation bool `json:"keep_identifier_quotation"`
+
+	// BestEffort specifies whether the normalizer should ignore the errors encountered by the lexer.
+	// By default, the first lexer error (e.g. an unterminated comment) is returned along with the normalized SQL.
+	BestEffort bool `json:"best_effort"`
+
+	// Placeholders are the placeholders the literals of the input were replaced with besides ?,
+	// e.g. ?str and ?num for an obfuscator configured with WithStringPlaceholder and WithNumberPlaceholder.
+	// They are kept whole and grouped like ?, e.g. IN (?str, ?str) is normalized to IN ( ?str ),
+	// and so are the numbered ones, e.g. $1 and $2 for $.
+	Placeholders []string `json:"placeholders"`
 }
 
 type normalizerOption func(*normalizerConfig)
 
+// tracksTables returns true if the normalizer needs to find the table names,
+// either to collect them or to collect metadata derived from them.
+func (c *normalizerConfig) tracksTables() bool {
+	return c.CollectTables || c.CollectTableAccess || c.CollectColumns || c.CollectAliases || c.CollectTableNames
+}
+
 func WithCollectTables(collectTables bool) normalizerOption {
 	return func(c *normalizerConfig) {
 		c.CollectTables = collectTables
@@ -74,6 +116,42 @@ func WithCollectProcedures(collectProcedure bool) normalizerOption {
 	}
 }
 
+func WithCollectTableAccess(collectTableAccess bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectTableAccess = collectTableAccess
+	}
+}
+
+func WithCollectColumns(collectColumns bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectColumns = collectColumns
+	}
+}
+
+func WithCollectAliases(collectAliases bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectAliases = collectAliases
+	}
+}
+
+func WithCollectStatements(collectStatements bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectStatements = collectStatements
+	}
+}
+
+func WithPlaceholders(placeholders ...string) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.Placeholders = placeholders
+	}
+}
+
+func WithCollectTableNames(collectTableNames bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.CollectTableNames = collectTableNames
+	}
+}
+
 func WithRemoveSpaceBetweenParentheses(removeSpaceBetweenParentheses bool) normalizerOption {
 	return func(c *normalizerConfig) {
 		c.RemoveSpaceBetweenParentheses = removeSpaceBetweenParentheses
@@ -92,16 +170,385 @@ func WithKeepIdentifierQuotation(keepIdentifierQuotation bool) normalizerOption
 	}
 }
 
+func WithBestEffort(bestEffort bool) normalizerOption {
+	return func(c *normalizerConfig) {
+		c.BestEffort = bestEffort
+	}
+}
+
 type StatementMetadata struct {
-	Size       int      `json:"size"`
-	Tables     []string `json:"tables"`
-	Comments   []string `json:"comments"`
-	Commands   []string `json:"commands"`
-	Procedures []string `json:"procedures"`
+	Size          int               `json:"size"`
+	Tables        []string          `json:"tables"`
+	Comments      []string          `json:"comments"`
+	Commands      []string          `json:"commands"`
+	Procedures    []string          `json:"procedures"`
+	Columns       []string          `json:"columns,omitempty"`
+	Aliases       map[string]string `json:"aliases,omitempty"`
+	TableAccesses []TableAccess     `json:"table_accesses,omitempty"`
+	Statements    []StatementInfo   `json:"statements,omitempty"`
+	TableNames    []TableName       `json:"table_names,omitempty"`
+}
+
+type AccessKind string
+
+const (
+	// AccessRead is a table read, e.g. SELECT ... FROM table
+	AccessRead AccessKind = "read"
+	// AccessWrite is a table write, e.g. INSERT INTO, UPDATE, MERGE INTO, DELETE FROM or TRUNCATE
+	AccessWrite AccessKind = "write"
+	// AccessCreate is a table creation, e.g. CREATE TABLE or SELECT ... INTO
+	AccessCreate AccessKind = "create"
+	// AccessDrop is a table drop, e.g. DROP TABLE
+	AccessDrop AccessKind = "drop"
+	// AccessAlter is a table alteration, e.g. ALTER TABLE
+	AccessAlter AccessKind = "alter"
+)
+
+// TableAccess describes how a table is accessed, and the command that accesses it.
+type TableAccess struct {
+	Table   string     `json:"table"`
+	Access  AccessKind `json:"access"`
+	Command string     `json:"command"`
+}
+
+// StatementInfo holds the metadata of a single statement of a multi-statement input.
+// Commands are all the commands of the statement, in order, e.g. DELETE and SELECT for DELETE FROM b WHERE id IN (SELECT ...).
+type StatementInfo struct {
+	Commands      []string      `json:"commands"`
+	Tables        []string      `json:"tables"`
+	Comments      []string      `json:"comments"`
+	Procedures    []string      `json:"procedures"`
+	TableAccesses []TableAccess `json:"table_accesses,omitempty"`
+	Start         Position      `json:"start"`
+	End           Position      `json:"end"`
 }
 
 type groupablePlaceholder struct {
-	groupable bool
+	groupable    bool
+	obfuscator   *Obfuscator // the obfuscator that wrote the placeholders, if any, to recognize its own placeholders
+	placeholders []string    // the placeholders of the input, see WithPlaceholders
+}
+
+// isPlaceholder reports whether the value is a placeholder, either ? or one the obfuscator writes, e.g. ?str or $1,
+// or one the input was obfuscated with.
+func (g *groupablePlaceholder) isPlaceholder(value string) bool {
+	if value == NumberPlaceholder || value == StringPlaceholder {
+		return true
+	}
+	for _, placeholder := range g.placeholders {
+		if isPlaceholderOf(value, placeholder, true) {
+			return true
+		}
+	}
+	return g.obfuscator != nil && g.obfuscator.isPlaceholder(value)
+}
+
+// clause is the part of a statement a token belongs to, as far as column collection is concerned.
+type clause int
+
+const (
+	otherClause         clause = iota
+	selectClause               // SELECT a, b
+	predicateClause            // WHERE, ON and HAVING
+	setClause                  // UPDATE ... SET
+	insertColumnsClause        // INSERT INTO table (a, b)
+	orderByClause              // ORDER BY and GROUP BY
+)
+
+// metadataContext holds the state carried from one token to the next while collecting metadata.
+type metadataContext struct {
+	dbms       DBMSType        // The DBMS of the query, which decides how table names are split
+	dictionary *dictionary     // The words the DBMS gives a meaning to
+	ctes       map[string]bool // Holds the CTEs that are currently being processed
+	command    string          // The last command that can access tables, e.g. SELECT or INSERT
+
+	clause        clause            // The clause the current token belongs to
+	clauses       []clause          // The clauses enclosing the current parentheses
+	call          string            // The function the current parentheses belong to, if any, e.g. EXTRACT
+	calls         []string          // The functions of the parentheses enclosing the current ones
+	aliasedTable  string            // The table that was just collected, and that may be followed by an alias
+	aliases       map[string]string // The table aliases, e.g. u -> users
+	insertColumns bool              // Whether the next parenthesis opens the column list of an INSERT
+	terminator    string            // The statement terminator, ; unless changed by a DB2 --#SET TERMINATOR directive
+	directives    bool              // Whether the DBMS changes the terminator with directives, see Dialect.TerminatorDirectives
+	arrayJoin     bool              // Whether the last JOIN is a clickhouse ARRAY JOIN, which joins an array rather than a table
+	showColumns   bool              // Whether the SHOW command lists columns, the only objects shown FROM a table
+}
+
+func newMetadataContext(dbms DBMSType) *metadataContext {
+	dialect := dialectFor(dbms)
+	return &metadataContext{
+		dbms:       dbms,
+		dictionary: dialect.dictionary,
+		ctes:       make(map[string]bool),
+		aliases:    make(map[string]string),
+		terminator: ";",
+		directives: dialect.TerminatorDirectives,
+	}
+}
+
+// metadataContextPool holds the metadata contexts the normalizer reuses from one input to the next.
+var metadataContextPool = sync.Pool{
+	New: func() any {
+		return newMetadataContext("")
+	},
+}
+
+// getMetadataContext returns a metadata context from the pool, as newMetadataContext would.
+// It must be handed back with putMetadataContext once the metadata is collected.
+func getMetadataContext(dbms DBMSType) *metadataContext {
+	c := metadataContextPool.Get().(*metadataContext)
+	// the clear builtin needs Go 1.21, the compiler turns these loops into the same map clearing
+	for cte := range c.ctes {
+		delete(c.ctes, cte)
+	}
+	for alias := range c.aliases {
+		delete(c.aliases, alias)
+	}
+	dialect := dialectFor(dbms)
+	*c = metadataContext{
+		dbms:       dbms,
+		dictionary: dialect.dictionary,
+		ctes:       c.ctes,
+		clauses:    c.clauses[:0],
+		calls:      c.calls[:0],
+		aliases:    c.aliases,
+		terminator: ";",
+		directives: dialect.TerminatorDirectives,
+	}
+	return c
+}
+
+func putMetadataContext(c *metadataContext) {
+	metadataContextPool.Put(c)
+}
+
+// trackClause keeps track of the clause the token belongs to, so that columns can be told apart from other identifiers.
+func (c *metadataContext) trackClause(token *keywordToken, lastToken *keywordToken) {
+	switch token.Type {
+	case PUNCTUATION:
+		switch token.Value {
+		case "(":
+			c.clauses = append(c.clauses, c.clause)
+			c.calls = append(c.calls, c.call)
+			c.call = ""
+			if lastToken.Type == FUNCTION {
+				c.call = strings.ToUpper(identifierName(lastToken.Value))
+			}
+			if c.insertColumns {
+				c.clause = insertColumnsClause
+			}
+		case ")":
+			if len(c.clauses) > 0 {
+				c.clause = c.clauses[len(c.clauses)-1]
+				c.clauses = c.clauses[:len(c.clauses)-1]
+				c.call = c.calls[len(c.calls)-1]
+				c.calls = c.calls[:len(c.calls)-1]
+			}
+		}
+	case IDENT:
+		switch token.Keyword {
+		case "SELECT":
+			c.clause = selectClause
+		case "FROM":
+			if !fromArgumentFunctions[c.call] {
+				c.clause = otherClause
+			}
+		case "JOIN", "STRAIGHT_JOIN", "INTO", "UPDATE", "TABLE", "USING", "VALUES", "LIMIT", "OFFSET", "UNION", "INTERSECT", "EXCEPT", "RETURNING", "WINDOW", "FETCH":
+			c.clause = otherClause
+		case "WHERE", "ON", "HAVING":
+			c.clause = predicateClause
+		case "SET":
+			if c.command == "UPDATE" || c.command == "MERGE" {
+				c.clause = setClause
+			}
+		case "BY":
+			if lastToken.Keyword == "ORDER" || lastToken.Keyword == "GROUP" {
+				c.clause = orderByClause
+			}
+		}
+	}
+	if token.Type != WS && token.Type != COMMENT && token.Type != MULTILINE_COMMENT {
+		c.insertColumns = false
+	}
+}
+
+// isTableIndicator reports whether a table comes after the keyword, e.g. FROM, or USING in MERGE INTO t USING s.
+func (c *metadataContext) isTableIndicator(keyword string) bool {
+	if c.command == "SHOW" && keyword == "FROM" {
+		// e.g. the schema of SHOW TABLES FROM hive.web, but the table of SHOW COLUMNS FROM hive.web.orders
+		return c.showColumns
+	}
+	return c.dictionary.isTableIndicator(keyword) || keyword == "USING" && c.command == "MERGE"
+}
+
+// trackAlias records the alias of the table that was just collected, e.g. FROM users u or FROM users AS u.
+// It returns true if the token is an alias.
+func (c *metadataContext) trackAlias(token *keywordToken, tokenVal string) bool {
+	if c.aliasedTable == "" || token.Type == WS || token.Type == COMMENT || token.Type == MULTILINE_COMMENT {
+		return false
+	}
+	if token.Type == IDENT && token.Keyword == "AS" {
+		// the alias comes next
+		return false
+	}
+	if token.Type == QUOTED_IDENT || token.Type == IDENT && token.Keyword == "" {
+		// none of the words the dictionary knows is an alias, e.g. FOR in FROM jobs FOR UPDATE
+		c.aliases[tokenVal] = c.aliasedTable
+		c.aliasedTable = ""
+		return true
+	}
+	c.aliasedTable = ""
+	return false
+}
+
+// isColumn returns true if the identifier is a column name in the current clause.
+func (c *metadataContext) isColumn(token *keywordToken, tokenVal string, lastToken *keywordToken) bool {
+	if c.clause == otherClause || token.Type == FUNCTION || token.Keyword != "" {
+		// none of the words the dictionary knows is a column, e.g. NULLS in ORDER BY a NULLS LAST or CURRENT_DATE
+		return false
+	}
+	if strings.HasSuffix(tokenVal, ".") {
+		// e.g. the qualifier of t.*
+		return false
+	}
+	if lastToken.Keyword == "AS" {
+		// e.g. an alias or a type in CAST(a AS int)
+		return false
+	}
+	if lastToken.Value == "(" && c.call == "EXTRACT" {
+		// the date part, e.g. YEAR in EXTRACT(YEAR FROM ts)
+		return false
+	}
+	switch lastToken.Type {
+	case IDENT, QUOTED_IDENT:
+		// an identifier right after an expression is an implicit alias, e.g. SELECT a b,
+		// or a word the dictionary does not know, e.g. TIME and ZONE in ts AT TIME ZONE 'UTC'
+		return (c.dictionary.isKeyword(lastToken) && lastToken.Keyword != "END") || isAliasStopWord(lastToken.Keyword)
+	}
+	if c.clause == selectClause {
+		switch lastToken.Type {
+		case STRING, NUMBER:
+			return false
+		case PUNCTUATION:
+			return lastToken.Value != ")"
+		}
+	}
+	return true
+}
+
+// collectAliases copies the table aliases to the collected aliases, if they are collected.
+func (c *metadataContext) collectAliases(aliases map[string]string) {
+	if aliases == nil {
+		return
+	}
+	for alias, table := range c.aliases {
+		aliases[alias] = table
+	}
+}
+
+// resolveColumns replaces the table aliases that qualify the columns with the table names, e.g. u.id becomes users.id.
+func (c *metadataContext) resolveColumns(columns []string) {
+	for i, column := range columns {
+		if dot := strings.LastIndexByte(column, '.'); dot > 0 {
+			if table, ok := c.aliases[column[:dot]]; ok {
+				columns[i] = table + column[dot:]
+			}
+		}
+	}
+}
+
+// statementsCollector collects the metadata of each statement of a multi-statement input,
+// splitting the statements as their tokens are normalized.
+type statementsCollector struct {
+	splitter  *statementSplitter
+	index     int                // the index of the last token returned by scan
+	infos     []StatementInfo    // the statements collected so far
+	metadata  *StatementMetadata // the metadata of the current statement
+	lastToken keywordToken       // the last token of the statement that is not whitespace or comment
+	context   *metadataContext   // the metadata context of the statement
+}
+
+// newStatementsCollector returns a collector of the statements of the input of the lexer.
+// It scans the whole input at once, as telling the end of a statement needs the tokens that follow,
+// the tokens are then handed to the normalizer by scan.
+func newStatementsCollector(lexer *Lexer) *statementsCollector {
+	return &statementsCollector{
+		splitter: newStatementSplitter(lexer, lexer.ScanAll()),
+		index:    -1,
+		metadata: newStatementInfoMetadata(),
+		context:  newMetadataContext(lexer.config.DBMS),
+	}
+}
+
+// newStatementInfoMetadata returns an empty StatementMetadata for the metadata of a single statement.
+func newStatementInfoMetadata() *StatementMetadata {
+	return &StatementMetadata{
+		Tables:     []string{},
+		Comments:   []string{},
+		Commands:   []string{},
+		Procedures: []string{},
+	}
+}
+
+// statementsNormalizer collects the metadata of the statements, whatever the configuration of the normalizer
+var statementsNormalizer = NewNormalizer(
+	WithCollectCommands(true),
+	WithCollectTables(true),
+	WithCollectComments(true),
+	WithCollectProcedures(true),
+	WithCollectTableAccess(true),
+)
+
+// scan returns the next token of the input, the same way as Lexer.Scan.
+func (c *statementsCollector) scan() Token {
+	if c.index+1 >= len(c.splitter.tokens) {
+		return Token{Type: EOF}
+	}
+	c.index++
+	return c.splitter.tokens[c.index]
+}
+
+// collect attributes the metadata of the token last returned by scan to the statement it belongs to.
+// The terminators of the statements are not part of any statement.
+func (c *statementsCollector) collect(token keywordToken) {
+	if c.splitter.next(c.index) {
+		c.endStatement()
+		return
+	}
+	if c.splitter.first < 0 {
+		// the whitespace before the statement
+		return
+	}
+	statementsNormalizer.collectMetadata(&token, &c.lastToken, c.metadata, c.context)
+	if token.Type != WS && token.Type != COMMENT && token.Type != MULTILINE_COMMENT {
+		c.lastToken = token
+	}
+}
+
+// endStatement records the metadata of the current statement, unless it has nothing else than comments.
+func (c *statementsCollector) endStatement() {
+	if statement, ok := c.splitter.end(); ok {
+		dedupeStatementMetadata(c.metadata)
+		c.infos = append(c.infos, StatementInfo{
+			Commands:      c.metadata.Commands,
+			Tables:        c.metadata.Tables,
+			Comments:      c.metadata.Comments,
+			Procedures:    c.metadata.Procedures,
+			TableAccesses: c.metadata.TableAccesses,
+			Start:         statement.Start,
+			End:           statement.End,
+		})
+	}
+	c.metadata = newStatementInfoMetadata()
+	c.lastToken = keywordToken{}
+	c.context = newMetadataContext(c.context.dbms)
+}
+
+// statementInfos returns the metadata of each statement, once all the tokens are collected.
+func (c *statementsCollector) statementInfos() []StatementInfo {
+	c.endStatement()
+	return c.infos
 }
 
 type Normalizer struct {
@@ -120,81 +567,251 @@ func NewNormalizer(opts ...normalizerOption) *Normalizer {
 	return &normalizer
 }
 
+// newStatementMetadata returns an empty StatementMetadata with the fields the normalizer collects.
+func (n *Normalizer) newStatementMetadata() *StatementMetadata {
+	statementMetadata := &StatementMetadata{
+		Tables:     []string{},
+		Comments:   []string{},
+		Commands:   []string{},
+		Procedures: []string{},
+	}
+	if n.config.CollectColumns {
+		statementMetadata.Columns = []string{}
+	}
+	if n.config.CollectAliases {
+		statementMetadata.Aliases = map[string]string{}
+	}
+	return statementMetadata
+}
+
 // Normalize takes an input SQL string and returns a normalized SQL string, a StatementMetadata struct, and an error.
 // The normalizer collapses input SQL into compact format, groups obfuscated values into single placeholder,
 // and collects metadata such as table names, comments, and commands.
+// If the lexer encountered a problem, the error is a *LexError and the normalized SQL is the best effort result,
+// unless the normalizer is configured to ignore errors with WithBestEffort.
 func (n *Normalizer) Normalize(input string, lexerOpts ...lexerOption) (normalizedSQL string, statementMetadata *StatementMetadata, err error) {
-	lexer := New(
+	lexer := getLexer(
 		input,
 		lexerOpts...,
 	)
+	defer putLexer(lexer)
+	lexer.config.placeholders = n.config.Placeholders
 
-	var normalizedSQLBuilder strings.Builder
+	normalizedSQLBuilder := getBuffer()
+	defer putBuffer(normalizedSQLBuilder)
 
-	statementMetadata = &StatementMetadata{
-		Tables:     []string{},
-		Comments:   []string{},
-		Commands:   []string{},
-		Procedures: []string{},
-	}
+	statementMetadata = n.newStatementMetadata()
 
-	var lastToken Token // The last token that is not whitespace or comment
-	var groupablePlaceholder groupablePlaceholder
+	var lastToken keywordToken // The last token that is not whitespace or comment
+	groupablePlaceholder := groupablePlaceholder{placeholders: n.config.Placeholders}
 
-	ctes := make(map[string]bool) // Holds the CTEs that are currently being processed
+	metadataContext := getMetadataContext(lexer.config.DBMS)
+	defer putMetadataContext(metadataContext)
+
+	var statements *statementsCollector
+	if n.config.CollectStatements {
+		statements = newStatementsCollector(lexer)
+	}
 
 	for {
-		token := lexer.Scan()
-		if token.Type == EOF {
+		var scanned Token
+		if statements != nil {
+			scanned = statements.scan()
+		} else {
+			scanned = lexer.Scan()
+		}
+		if scanned.Type == EOF {
 			break
 		}
-		n.collectMetadata(&token, &lastToken, statementMetadata, ctes)
-		n.normalizeSQL(&token, &lastToken, &normalizedSQLBuilder, &groupablePlaceholder, lexerOpts...)
+		token := newKeywordToken(scanned)
+		if statements != nil {
+			statements.collect(token)
+		}
+		n.collectMetadata(&token, &lastToken, statementMetadata, metadataContext)
+		n.normalizeSQL(&token, &lastToken, normalizedSQLBuilder, &groupablePlaceholder, metadataContext.dictionary, lexerOpts...)
 	}
 
 	normalizedSQL = normalizedSQLBuilder.String()
 
 	// Dedupe collected metadata
+	metadataContext.resolveColumns(statementMetadata.Columns)
+	metadataContext.collectAliases(statementMetadata.Aliases)
+	dedupeStatementMetadata(statementMetadata)
+	if statements != nil {
+		statementMetadata.Statements = statements.statementInfos()
+	}
+
+	return n.trimNormalizedSQL(normalizedSQL, metadataContext.terminator), statementMetadata, n.lexerError(lexer)
+}
+
+// NormalizeTo reads the SQL from r and writes it normalized to w, the same way as Normalize, and returns its metadata.
+// The output is written as the tokens are scanned, so that large inputs, e.g. SQL dumps or migration scripts,
+// are normalized without holding them in memory. See ReaderLexer for the tokens that are still held whole.
+// The metadata of each statement is not collected, as splitting the statements needs the whole input.
+// It returns the first error returned by r or w, or else the lexer error the same way as Normalize.
+func (n *Normalizer) NormalizeTo(w io.Writer, r io.Reader, lexerOpts ...lexerOption) (statementMetadata *StatementMetadata, err error) {
+	lexer := NewReaderLexer(r, lexerOpts...)
+	lexer.lexer.config.placeholders = n.config.Placeholders
+	normalizedSQL := newTrimmingWriter(w, n.trailingTerminator(";"))
+
+	statementMetadata = n.newStatementMetadata()
+
+	var lastToken keywordToken // The last token that is not whitespace or comment
+	groupablePlaceholder := groupablePlaceholder{placeholders: n.config.Placeholders}
+
+	var metadataContext *metadataContext
+
+	for {
+		scanned := lexer.Scan()
+		if scanned.Type == EOF {
+			break
+		}
+		token := newKeywordToken(scanned)
+		if metadataContext == nil {
+			// the DBMS might be detected from the beginning of the input by the first Scan
+			metadataContext = newMetadataContext(lexer.lexer.config.DBMS)
+		}
+		n.collectMetadata(&token, &lastToken, statementMetadata, metadataContext)
+		normalizedSQL.terminator = n.trailingTerminator(metadataContext.terminator)
+		n.normalizeSQL(&token, &lastToken, normalizedSQL, &groupablePlaceholder, metadataContext.dictionary, lexerOpts...)
+	}
+
+	// Dedupe collected metadata
+	if metadataContext != nil {
+		metadataContext.resolveColumns(statementMetadata.Columns)
+		metadataContext.collectAliases(statementMetadata.Aliases)
+	}
 	dedupeStatementMetadata(statementMetadata)
 
-	return n.trimNormalizedSQL(normalizedSQL), statementMetadata, nil
+	if lexer.err != nil {
+		return statementMetadata, lexer.err
+	}
+	if err := normalizedSQL.Close(); err != nil {
+		return statementMetadata, err
+	}
+	return statementMetadata, n.lexerError(lexer.lexer)
 }
 
-func (n *Normalizer) collectMetadata(token *Token, lastToken *Token, statementMetadata *StatementMetadata, ctes map[string]bool) {
+func (n *Normalizer) collectMetadata(token *keywordToken, lastToken *keywordToken, statementMetadata *StatementMetadata, metadataContext *metadataContext) {
+	if token.Type == COMMENT && metadataContext.directives {
+		metadataContext.terminator = terminatorOf(token.Value, metadataContext.terminator)
+	}
 	if n.config.CollectComments && (token.Type == COMMENT || token.Type == MULTILINE_COMMENT) {
 		// Collect comments
 		statementMetadata.Comments = append(statementMetadata.Comments, token.Value)
 	} else if token.Type == IDENT || token.Type == QUOTED_IDENT || token.Type == FUNCTION {
-		tokenVal := token.Value
+		rawTokenVal, tokenVal := token.Value, token.Value
 		if token.Type == QUOTED_IDENT {
 			// We always want to trim the quotes for collected metadata such as table names
 			// This is because the metadata is used as tags, and we don't want them to be normalized as underscores later on
-			tokenVal = trimQuotes(tokenVal, tokenVal[0:1], tokenVal[len(tokenVal)-1:])
+			tokenVal = unquoteIdentifier(tokenVal)
 			if !n.config.KeepIdentifierQuotation {
 				token.Value = tokenVal
 			}
 		}
-		if n.config.CollectCommands && isCommand(strings.ToUpper(tokenVal)) {
+		if n.config.tracksTables() {
+			metadataContext.trackClause(token, lastToken)
+		}
+		if n.config.CollectColumns || n.config.CollectAliases {
+			if metadataContext.trackAlias(token, tokenVal) {
+				return
+			}
+		}
+		upperTokenVal := token.Keyword
+		dictionary := metadataContext.dictionary
+		if upperTokenVal == "JOIN" {
+			metadataContext.arrayJoin = lastToken.Keyword == "ARRAY"
+		}
+		if lastToken.Keyword == "SHOW" {
+			metadataContext.showColumns = strings.EqualFold(tokenVal, "COLUMNS")
+		}
+		if dictionary.isCommand(upperTokenVal) && upperTokenVal != "JOIN" && upperTokenVal != "STRAIGHT_JOIN" {
+			// Keep track of the command, joins are part of the command that contains them
+			metadataContext.command = upperTokenVal
+		}
+		if n.config.CollectCommands && dictionary.isCommand(upperTokenVal) {
 			// Collect commands
-			statementMetadata.Commands = append(statementMetadata.Commands, strings.ToUpper(tokenVal))
-		} else if strings.ToUpper(lastToken.Value) == "WITH" && token.Type == IDENT {
+			statementMetadata.Commands = append(statementMetadata.Commands, upperTokenVal)
+		} else if lastToken.Keyword == "WITH" && token.Type == IDENT {
 			// Collect CTEs so we can skip them later in table collection
-			ctes[tokenVal] = true
-		} else if n.config.CollectTables && isTableIndicator(strings.ToUpper(lastToken.Value)) && !isSQLKeyword(token) {
+			metadataContext.ctes[tokenVal] = true
+		} else if n.config.tracksTables() && metadataContext.isTableIndicator(lastToken.Keyword) && !dictionary.keywords[token.Keyword] &&
+			!(lastToken.Keyword == "JOIN" && metadataContext.arrayJoin) && !(lastToken.Keyword == "FROM" && fromArgumentFunctions[metadataContext.call]) {
+			// neither keywords nor the functions named after one are tables, e.g. CROSS JOIN UNNEST(items),
+			// and neither are the arguments after FROM in EXTRACT(YEAR FROM ts)
+			// Keep track of the table so that we can collect its alias
+			metadataContext.aliasedTable = tokenVal
+			if metadataContext.command == "INSERT" && lastToken.Keyword == "INTO" {
+				metadataContext.insertColumns = true
+			}
 			// Collect table names the token is not a CTE
-			if _, ok := ctes[tokenVal]; !ok {
-				statementMetadata.Tables = append(statementMetadata.Tables, tokenVal)
+			if _, ok := metadataContext.ctes[tokenVal]; !ok {
+				if n.config.CollectTables {
+					statementMetadata.Tables = append(statementMetadata.Tables, tokenVal)
+				}
+				if n.config.CollectTableNames {
+					statementMetadata.TableNames = append(statementMetadata.TableNames, ParseTableName(rawTokenVal, metadataContext.dbms))
+				}
+				if n.config.CollectTableAccess {
+					statementMetadata.TableAccesses = append(statementMetadata.TableAccesses, TableAccess{
+						Table:   tokenVal,
+						Access:  tableAccessKind(metadataContext.command, lastToken.Keyword),
+						Command: metadataContext.command,
+					})
+				}
 			}
 		} else if n.config.CollectProcedure && isProcedure(lastToken) {
 			// Collect procedure names
 			statementMetadata.Procedures = append(statementMetadata.Procedures, tokenVal)
+		} else if n.config.CollectColumns && metadataContext.isColumn(token, tokenVal, lastToken) {
+			// Collect column names
+			statementMetadata.Columns = append(statementMetadata.Columns, tokenVal)
+		}
+	} else {
+		if n.config.tracksTables() {
+			metadataContext.trackClause(token, lastToken)
+		}
+		if n.config.CollectColumns || n.config.CollectAliases {
+			metadataContext.trackAlias(token, token.Value)
 		}
 	}
 }
 
-func (n *Normalizer) normalizeSQL(token *Token, lastToken *Token, normalizedSQLBuilder *strings.Builder, groupablePlaceholder *groupablePlaceholder, lexerOpts ...lexerOption) {
-	if token.Type != WS && token.Type != COMMENT && token.Type != MULTILINE_COMMENT {
-		if token.Type == DOLLAR_QUOTED_FUNCTION && token.Value != StringPlaceholder {
+// tableAccessKind returns how a table is accessed by the command,
+// given the table indicator that comes right before the table name.
+func tableAccessKind(command string, tableIndicator string) AccessKind {
+	switch tableIndicator {
+	case "FROM":
+		if command == "DELETE" {
+			return AccessWrite
+		}
+		return AccessRead
+	case "JOIN", "STRAIGHT_JOIN", "CLONE", "USING":
+		return AccessRead
+	case "INTO":
+		if command == "SELECT" {
+			// SELECT ... INTO creates a new table
+			return AccessCreate
+		}
+	}
+
+	switch command {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "TRUNCATE":
+		return AccessWrite
+	case "CREATE":
+		return AccessCreate
+	case "DROP":
+		return AccessDrop
+	case "ALTER":
+		return AccessAlter
+	default:
+		return AccessRead
+	}
+}
+
+func (n *Normalizer) normalizeSQL(token *keywordToken, lastToken *keywordToken, normalizedSQLBuilder io.StringWriter, groupablePlaceholder *groupablePlaceholder, dictionary *dictionary, lexerOpts ...lexerOption) {
+	if token.Type != WS && token.Type != COMMENT && token.Type != MULTILINE_COMMENT && token.Type != FORMAT_DATA {
+		if token.Type == DOLLAR_QUOTED_FUNCTION && strings.HasPrefix(token.Value, "$func$") {
 			// if the token is a dollar quoted function and it is not obfuscated,
 			// we need to recusively normalize the content of the dollar quoted function
 			quotedFunc := token.Value[6 : len(token.Value)-6] // remove the $func$ prefix and suffix
@@ -212,7 +829,7 @@ func (n *Normalizer) normalizeSQL(token *Token, lastToken *Token, normalizedSQLB
 
 		if !n.config.KeepSQLAlias {
 			// discard SQL alias
-			if strings.ToUpper(token.Value) == "AS" {
+			if token.Keyword == "AS" {
 				// if current token is AS, then continue to next token
 				// because without seeing the next token, we cannot
 				// determine if the current token is an alias or not
@@ -220,8 +837,8 @@ func (n *Normalizer) normalizeSQL(token *Token, lastToken *Token, normalizedSQLB
 				return
 			}
 
-			if strings.ToUpper(lastToken.Value) == "AS" {
-				if token.Type == IDENT && !isSQLKeyword(token) {
+			if lastToken.Keyword == "AS" {
+				if token.Type == IDENT && !dictionary.isKeyword(token) {
 					// if the last token is AS and the current token is IDENT,
 					// then the current token is an alias, so we discard it
 					*lastToken = *token
@@ -231,7 +848,7 @@ func (n *Normalizer) normalizeSQL(token *Token, lastToken *Token, normalizedSQLB
 					// this could be a CTE like WITH ... AS (...),
 					// so we do not discard the current token
 					n.appendWhitespace(lastToken, token, normalizedSQLBuilder)
-					n.writeToken(lastToken, normalizedSQLBuilder)
+					n.writeToken(lastToken, normalizedSQLBuilder, dictionary)
 				}
 			}
 		}
@@ -245,22 +862,28 @@ func (n *Normalizer) normalizeSQL(token *Token, lastToken *Token, normalizedSQLB
 
 		// determine if we should add a whitespace
 		n.appendWhitespace(lastToken, token, normalizedSQLBuilder)
-		n.writeToken(token, normalizedSQLBuilder)
+		n.writeToken(token, normalizedSQLBuilder, dictionary)
 
 		*lastToken = *token
 	}
 }
 
-func (n *Normalizer) writeToken(token *Token, normalizedSQLBuilder *strings.Builder) {
-	if n.config.UppercaseKeywords && isSQLKeyword(token) {
-		normalizedSQLBuilder.WriteString(strings.ToUpper(token.Value))
+func (n *Normalizer) writeToken(token *keywordToken, normalizedSQLBuilder io.StringWriter, dictionary *dictionary) {
+	if n.config.UppercaseKeywords && dictionary.isKeyword(token) {
+		if len(token.Keyword) != len(token.Value) {
+			// an abbreviation, e.g. teradata SEL, is uppercased rather than expanded
+			normalizedSQLBuilder.WriteString(strings.ToUpper(token.Value))
+			return
+		}
+		normalizedSQLBuilder.WriteString(token.Keyword)
 	} else {
 		normalizedSQLBuilder.WriteString(token.Value)
 	}
 }
 
-func (n *Normalizer) isObfuscatedValueGroupable(token *Token, lastToken *Token, groupablePlaceholder *groupablePlaceholder, normalizedSQLBuilder *strings.Builder) bool {
-	if token.Value == NumberPlaceholder || token.Value == StringPlaceholder {
+func (n *Normalizer) isObfuscatedValueGroupable(token *keywordToken, lastToken *keywordToken, groupablePlaceholder *groupablePlaceholder, normalizedSQLBuilder io.StringWriter) bool {
+	isPlaceholder := groupablePlaceholder.isPlaceholder(token.Value)
+	if isPlaceholder {
 		if lastToken.Value == "(" || lastToken.Value == "[" {
 			// if the last token is "(" or "[", and the current token is a placeholder,
 			// we know it's the start of groupable placeholders
@@ -271,7 +894,7 @@ func (n *Normalizer) isObfuscatedValueGroupable(token *Token, lastToken *Token,
 		}
 	}
 
-	if (lastToken.Value == NumberPlaceholder || lastToken.Value == StringPlaceholder) && token.Value == "," && groupablePlaceholder.groupable {
+	if token.Value == "," && groupablePlaceholder.groupable && groupablePlaceholder.isPlaceholder(lastToken.Value) {
 		return true
 	}
 
@@ -281,7 +904,7 @@ func (n *Normalizer) isObfuscatedValueGroupable(token *Token, lastToken *Token,
 		return false
 	}
 
-	if groupablePlaceholder.groupable && token.Value != NumberPlaceholder && token.Value != StringPlaceholder && lastToken.Value == "," {
+	if groupablePlaceholder.groupable && !isPlaceholder && lastToken.Value == "," {
 		// This is a tricky edge case. If we are inside a groupbale block, and the current token is not a placeholder,
 		// we not only want to write the current token to the normalizedSQLBuilder, but also write the last comma that we skipped.
 		// For example, (?, ARRAY[?, ?, ?]) should be normalized as (?, ARRAY[?])
@@ -292,7 +915,7 @@ func (n *Normalizer) isObfuscatedValueGroupable(token *Token, lastToken *Token,
 	return false
 }
 
-func (n *Normalizer) appendWhitespace(lastToken *Token, token *Token, normalizedSQLBuilder *strings.Builder) {
+func (n *Normalizer) appendWhitespace(lastToken *keywordToken, token *keywordToken, normalizedSQLBuilder io.StringWriter) {
 	// do not add a space between parentheses if RemoveSpaceBetweenParentheses is true
 	if n.config.RemoveSpaceBetweenParentheses && (lastToken.Type == FUNCTION || lastToken.Value == "(" || lastToken.Value == "[") {
 		return
@@ -302,6 +925,11 @@ func (n *Normalizer) appendWhitespace(lastToken *Token, token *Token, normalized
 		return
 	}
 
+	if token.Type == PUNCTUATION && (token.Value == "<" || token.Value == ">") || lastToken.Type == PUNCTUATION && lastToken.Value == "<" {
+		// do not add a space around the angle brackets of types, e.g. bigquery ARRAY<INT64>
+		return
+	}
+
 	switch token.Value {
 	case ",":
 	case ";":
@@ -317,11 +945,25 @@ func (n *Normalizer) appendWhitespace(lastToken *Token, token *Token, normalized
 	}
 }
 
-func (n *Normalizer) trimNormalizedSQL(normalizedSQL string) string {
-	if !n.config.KeepTrailingSemicolon {
-		// Remove trailing semicolon
-		normalizedSQL = strings.TrimSuffix(normalizedSQL, ";")
+// lexerError returns the first error encountered by the lexer, unless the normalizer is configured to ignore them.
+func (n *Normalizer) lexerError(lexer *Lexer) error {
+	if n.config.BestEffort {
+		return nil
+	}
+	return lexer.Err()
+}
+
+// trailingTerminator returns the terminator that is removed from the end of the normalized SQL, if any.
+func (n *Normalizer) trailingTerminator(terminator string) string {
+	if n.config.KeepTrailingSemicolon {
+		return ""
 	}
+	return terminator
+}
+
+func (n *Normalizer) trimNormalizedSQL(normalizedSQL string, terminator string) string {
+	// Remove trailing terminator, a semicolon unless changed by a DB2 --#SET TERMINATOR directive
+	normalizedSQL = strings.TrimSuffix(normalizedSQL, n.trailingTerminator(terminator))
 	return strings.TrimSpace(normalizedSQL)
 }
 
@@ -340,11 +982,46 @@ func dedupeCollectedMetadata(metadata []string) (dedupedMetadata []string, size
 	return dedupedMetadata, size
 }
 
+func dedupeTableAccesses(tableAccesses []TableAccess) []TableAccess {
+	if tableAccesses == nil {
+		return nil
+	}
+	dedupedTableAccesses := []TableAccess{}
+	var tableAccessesSeen = make(map[TableAccess]struct{})
+	for _, tableAccess := range tableAccesses {
+		if _, seen := tableAccessesSeen[tableAccess]; !seen {
+			tableAccessesSeen[tableAccess] = struct{}{}
+			dedupedTableAccesses = append(dedupedTableAccesses, tableAccess)
+		}
+	}
+	return dedupedTableAccesses
+}
+
+func dedupeTableNames(tableNames []TableName) []TableName {
+	if tableNames == nil {
+		return nil
+	}
+	dedupedTableNames := []TableName{}
+	var tableNamesSeen = make(map[TableName]struct{})
+	for _, tableName := range tableNames {
+		if _, seen := tableNamesSeen[tableName]; !seen {
+			tableNamesSeen[tableName] = struct{}{}
+			dedupedTableNames = append(dedupedTableNames, tableName)
+		}
+	}
+	return dedupedTableNames
+}
+
 func dedupeStatementMetadata(info *StatementMetadata) {
-	var tablesSize, commentsSize, commandsSize, procedureSize int
+	var tablesSize, commentsSize, commandsSize, procedureSize, columnsSize int
 	info.Tables, tablesSize = dedupeCollectedMetadata(info.Tables)
 	info.Comments, commentsSize = dedupeCollectedMetadata(info.Comments)
 	info.Commands, commandsSize = dedupeCollectedMetadata(info.Commands)
 	info.Procedures, procedureSize = dedupeCollectedMetadata(info.Procedures)
-	info.Size += tablesSize + commentsSize + commandsSize + procedureSize
+	if info.Columns != nil {
+		info.Columns, columnsSize = dedupeCollectedMetadata(info.Columns)
+	}
+	info.TableAccesses = dedupeTableAccesses(info.TableAccesses)
+	info.TableNames = dedupeTableNames(info.TableNames)
+	info.Size += tablesSize + commentsSize + commandsSize + procedureSize + columnsSize
 }
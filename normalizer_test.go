@@ -2,6 +2,7 @@ package sqllexer
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -394,6 +395,12 @@ multiline comment */
 			assert.NoError(t, err)
 			assert.Equal(t, test.expected, got)
 			assert.Equal(t, &test.statementMetadata, statementMetadata)
+
+			var streamed strings.Builder
+			statementMetadata, err = normalizer.NormalizeTo(&streamed, strings.NewReader(test.input))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, streamed.String())
+			assert.Equal(t, &test.statementMetadata, statementMetadata)
 		})
 	}
 }
@@ -1018,6 +1025,54 @@ func TestGroupObfuscatedValues(t *testing.T) {
 	}
 }
 
+func TestGroupCustomPlaceholders(t *testing.T) {
+	tests := []struct {
+		input        string
+		placeholders []string
+		expected     string
+	}{
+		{
+			input:        "SELECT * FROM users WHERE a IN (?str, ?str, ?str)",
+			placeholders: []string{"?str", "?num"},
+			expected:     "SELECT * FROM users WHERE a IN ( ?str )",
+		},
+		{
+			input:        "SELECT * FROM users WHERE a IN (?num, ?str) AND b = ?num",
+			placeholders: []string{"?str", "?num"},
+			expected:     "SELECT * FROM users WHERE a IN ( ?num ) AND b = ?num",
+		},
+		{
+			input:        "SELECT * FROM users WHERE a IN (:s, :s, :s)",
+			placeholders: []string{":s"},
+			expected:     "SELECT * FROM users WHERE a IN ( :s )",
+		},
+		{
+			input:        "SELECT * FROM users WHERE a IN ($1, $2, $3)",
+			placeholders: []string{"$"},
+			expected:     "SELECT * FROM users WHERE a IN ( $1 )",
+		},
+		{
+			input:        "SELECT * FROM users WHERE a IN (?, ?)",
+			placeholders: []string{"?str"},
+			expected:     "SELECT * FROM users WHERE a IN ( ? )",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			normalizer := NewNormalizer(WithPlaceholders(test.placeholders...))
+			got, _, err := normalizer.Normalize(test.input)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+
+			var streamed strings.Builder
+			_, err = normalizer.NormalizeTo(&streamed, strings.NewReader(test.input))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, streamed.String())
+		})
+	}
+}
+
 func TestNormalizerStoredProcedure(t *testing.T) {
 	tests := []struct {
 		input             string
@@ -1143,6 +1198,520 @@ func TestNormalizerKeepTrailingSemicolon(t *testing.T) {
 			normalizer := NewNormalizer(WithKeepTrailingSemicolon(true))
 			got, _, _ := normalizer.Normalize(test.input)
 			assert.Equal(t, test.expected, got)
+
+			var streamed strings.Builder
+			_, err := normalizer.NormalizeTo(&streamed, strings.NewReader(test.input))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, streamed.String())
+		})
+	}
+}
+
+func TestNormalizerTrimTerminator(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		lexerOpts []lexerOption
+	}{
+		{
+			input:    "SELECT * FROM users;",
+			expected: "SELECT * FROM users",
+		},
+		{
+			// the terminator set by the directive is trimmed like the semicolon
+			input:     "--#SET TERMINATOR @\nSELECT * FROM users @\n",
+			expected:  "SELECT * FROM users",
+			lexerOpts: []lexerOption{WithDBMS(DBMSDB2)},
+		},
+		{
+			input:     "--#SET TERMINATOR @\nSELECT * FROM users;",
+			expected:  "SELECT * FROM users;",
+			lexerOpts: []lexerOption{WithDBMS(DBMSDB2)},
+		},
+		{
+			// the directives are comments to the other DBMS
+			input:    "--#SET TERMINATOR @\nSELECT * FROM users;",
+			expected: "SELECT * FROM users",
+		},
+	}
+
+	normalizer := NewNormalizer()
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got, _, err := normalizer.Normalize(test.input, test.lexerOpts...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+
+			var streamed strings.Builder
+			_, err = normalizer.NormalizeTo(&streamed, strings.NewReader(test.input), test.lexerOpts...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, streamed.String())
+		})
+	}
+}
+
+func TestNormalizerTrinoShow(t *testing.T) {
+	tests := []struct {
+		input  string
+		tables []string
+	}{
+		{
+			// the catalog and the schema are not tables
+			input:  "SHOW TABLES FROM hive.web",
+			tables: []string{},
+		},
+		{
+			input:  "SHOW SCHEMAS FROM hive LIKE 'web%'",
+			tables: []string{},
+		},
+		{
+			input:  "SHOW COLUMNS FROM hive.web.page_views",
+			tables: []string{"hive.web.page_views"},
+		},
+		{
+			input:  "SHOW CREATE TABLE hive.web.page_views",
+			tables: []string{"hive.web.page_views"},
+		},
+		{
+			input:  "SHOW TABLES FROM hive.web; SELECT * FROM hive.web.page_views",
+			tables: []string{"hive.web.page_views"},
+		},
+	}
+
+	normalizer := NewNormalizer(WithCollectTables(true))
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, statementMetadata, err := normalizer.Normalize(test.input, WithDBMS(DBMSTrino))
+			assert.NoError(t, err)
+			assert.Equal(t, test.tables, statementMetadata.Tables)
+		})
+	}
+}
+
+func TestNormalizerCollectTableAccess(t *testing.T) {
+	tests := []struct {
+		input         string
+		tableAccesses []TableAccess
+		lexerOpts     []lexerOption
+	}{
+		{
+			input: "SELECT * FROM users u JOIN orders o ON u.id = o.user_id",
+			tableAccesses: []TableAccess{
+				{Table: "users", Access: AccessRead, Command: "SELECT"},
+				{Table: "orders", Access: AccessRead, Command: "SELECT"},
+			},
+		},
+		{
+			input: "INSERT INTO archive SELECT * FROM users WHERE id = ?",
+			tableAccesses: []TableAccess{
+				{Table: "archive", Access: AccessWrite, Command: "INSERT"},
+				{Table: "users", Access: AccessRead, Command: "SELECT"},
+			},
+		},
+		{
+			input: "UPDATE users SET name = ? WHERE id IN (SELECT user_id FROM orders)",
+			tableAccesses: []TableAccess{
+				{Table: "users", Access: AccessWrite, Command: "UPDATE"},
+				{Table: "orders", Access: AccessRead, Command: "SELECT"},
+			},
+		},
+		{
+			input: "DELETE FROM users WHERE id = ?",
+			tableAccesses: []TableAccess{
+				{Table: "users", Access: AccessWrite, Command: "DELETE"},
+			},
+		},
+		{
+			input: "MERGE INTO users USING staging ON users.id = staging.id WHEN MATCHED THEN UPDATE SET name = staging.name",
+			tableAccesses: []TableAccess{
+				{Table: "users", Access: AccessWrite, Command: "MERGE"},
+				{Table: "staging", Access: AccessRead, Command: "MERGE"},
+			},
+		},
+		{
+			input: "MERGE INTO users u USING (SELECT * FROM staging) s ON u.id = s.id WHEN NOT MATCHED THEN INSERT (id) VALUES (s.id)",
+			tableAccesses: []TableAccess{
+				{Table: "users", Access: AccessWrite, Command: "MERGE"},
+				{Table: "staging", Access: AccessRead, Command: "SELECT"},
+			},
+		},
+		{
+			input: "CREATE TABLE IF NOT EXISTS users (id int); ALTER TABLE users ADD name text; DROP TABLE IF EXISTS old_users; TRUNCATE TABLE logs",
+			tableAccesses: []TableAccess{
+				{Table: "users", Access: AccessCreate, Command: "CREATE"},
+				{Table: "users", Access: AccessAlter, Command: "ALTER"},
+				{Table: "old_users", Access: AccessDrop, Command: "DROP"},
+				{Table: "logs", Access: AccessWrite, Command: "TRUNCATE"},
+			},
+		},
+		{
+			input: "SELECT * INTO #tmp FROM users",
+			tableAccesses: []TableAccess{
+				{Table: "#tmp", Access: AccessCreate, Command: "SELECT"},
+				{Table: "users", Access: AccessRead, Command: "SELECT"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			input: "WITH recent AS (SELECT * FROM orders) DELETE FROM users WHERE id IN (SELECT user_id FROM recent)",
+			tableAccesses: []TableAccess{
+				{Table: "orders", Access: AccessRead, Command: "SELECT"},
+				{Table: "users", Access: AccessWrite, Command: "DELETE"},
+			},
+		},
+		{
+			input: "SELECT * FROM users; SELECT * FROM users",
+			tableAccesses: []TableAccess{
+				{Table: "users", Access: AccessRead, Command: "SELECT"},
+			},
+		},
+	}
+
+	normalizer := NewNormalizer(
+		WithCollectTableAccess(true),
+	)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, statementMetadata, err := normalizer.Normalize(test.input, test.lexerOpts...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.tableAccesses, statementMetadata.TableAccesses)
+			// table access is collected independently of the table names
+			assert.Empty(t, statementMetadata.Tables)
+		})
+	}
+}
+
+func TestNormalizerCollectColumns(t *testing.T) {
+	tests := []struct {
+		input     string
+		columns   []string
+		lexerOpts []lexerOption
+	}{
+		{
+			input:   "SELECT * FROM users",
+			columns: []string{},
+		},
+		{
+			input:   "SELECT id, name AS n, email address FROM users WHERE status = ? ORDER BY created_at DESC",
+			columns: []string{"id", "name", "email", "status", "created_at"},
+		},
+		{
+			input:   "SELECT u.id, o.total FROM users u JOIN orders AS o ON o.user_id = u.id WHERE u.active IS TRUE GROUP BY u.id, o.total",
+			columns: []string{"users.id", "orders.total", "orders.user_id", "users.active"},
+		},
+		{
+			input:   "SELECT u.*, count(o.id) FROM users u LEFT JOIN orders o ON o.user_id = u.id",
+			columns: []string{"orders.id", "orders.user_id", "users.id"},
+		},
+		{
+			input:   "INSERT INTO users (id, name) VALUES (?, ?)",
+			columns: []string{"id", "name"},
+		},
+		{
+			input:   "INSERT INTO archive (id) SELECT id FROM users WHERE deleted_at IS NOT NULL",
+			columns: []string{"id", "deleted_at"},
+		},
+		{
+			input:   "UPDATE users SET name = ?, visits = visits + ? WHERE id = ?",
+			columns: []string{"name", "visits", "id"},
+		},
+		{
+			input:   "DELETE FROM users WHERE id IN (SELECT user_id FROM orders WHERE total > ?) AND CAST(age AS int) > ?",
+			columns: []string{"id", "user_id", "total", "age"},
+		},
+		{
+			input:   "SELECT CASE WHEN a = ? THEN b ELSE c END label FROM t",
+			columns: []string{"a", "b", "c"},
+		},
+		{
+			input:   `SELECT "u"."name" FROM "users" "u" WITH (NOLOCK) WHERE [u].[id] = ?`,
+			columns: []string{"users.name", "users.id"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+			},
+		},
+		{
+			input:   "SELECT a FROM t ORDER BY a DESC NULLS LAST",
+			columns: []string{"a"},
+		},
+		{
+			input:   "SELECT CURRENT_DATE, ts AT TIME ZONE 'UTC' FROM t WHERE created_at AT TIME ZONE 'UTC' < CURRENT_TIMESTAMP",
+			columns: []string{"ts", "created_at"},
+		},
+		{
+			input:   "SELECT EXTRACT(YEAR FROM ts) FROM t WHERE SUBSTRING(name FROM ?) = ?",
+			columns: []string{"ts", "name"},
+		},
+		{
+			input:   "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET a = s.a WHEN NOT MATCHED THEN INSERT (id, a) VALUES (s.id, s.a)",
+			columns: []string{"t.id", "s.id", "a", "s.a", "id"},
+		},
+	}
+
+	normalizer := NewNormalizer(
+		WithCollectColumns(true),
+	)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, statementMetadata, err := normalizer.Normalize(test.input, test.lexerOpts...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.columns, statementMetadata.Columns)
+		})
+	}
+}
+
+func TestNormalizerCollectAliases(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		aliases   map[string]string
+		lexerOpts []lexerOption
+	}{
+		{
+			input:    "SELECT * FROM users",
+			expected: "SELECT * FROM users",
+			aliases:  map[string]string{},
+		},
+		{
+			input:    "SELECT u.name, o.total FROM users AS u JOIN orders o ON o.user_id = u.id",
+			expected: "SELECT u.name, o.total FROM users JOIN orders o ON o.user_id = u.id",
+			aliases:  map[string]string{"u": "users", "o": "orders"},
+		},
+		{
+			input:    "WITH recent AS (SELECT * FROM orders) SELECT r.id FROM recent r NATURAL JOIN customers",
+			expected: "WITH recent AS ( SELECT * FROM orders ) SELECT r.id FROM recent r NATURAL JOIN customers",
+			aliases:  map[string]string{"r": "recent"},
+		},
+		{
+			input:    "UPDATE users u SET name = ? FROM accounts a WHERE a.id = u.account_id",
+			expected: "UPDATE users u SET name = ? FROM accounts a WHERE a.id = u.account_id",
+			aliases:  map[string]string{"u": "users", "a": "accounts"},
+		},
+		{
+			input:    "SELECT * FROM jobs FOR UPDATE SKIP LOCKED",
+			expected: "SELECT * FROM jobs FOR UPDATE SKIP LOCKED",
+			aliases:  map[string]string{},
+		},
+		{
+			input:    "SELECT * FROM jobs j ORDER BY id FETCH FIRST ? ROWS ONLY",
+			expected: "SELECT * FROM jobs j ORDER BY id FETCH FIRST ? ROWS ONLY",
+			aliases:  map[string]string{"j": "jobs"},
+		},
+		{
+			input:    "SELECT id FROM jobs FETCH FIRST ? ROWS ONLY",
+			expected: "SELECT id FROM jobs FETCH FIRST ? ROWS ONLY",
+			aliases:  map[string]string{},
+		},
+		{
+			input:    "SELECT id FROM users EXCEPT SELECT id FROM admins INTERSECT SELECT id FROM staff UNION SELECT id FROM guests g",
+			expected: "SELECT id FROM users EXCEPT SELECT id FROM admins INTERSECT SELECT id FROM staff UNION SELECT id FROM guests g",
+			aliases:  map[string]string{"g": "guests"},
+		},
+		{
+			input:    "SELECT id FROM users MINUS SELECT id FROM admins",
+			expected: "SELECT id FROM users MINUS SELECT id FROM admins",
+			aliases:  map[string]string{},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSOracle),
+			},
+		},
+		{
+			input:    "SELECT * FROM users FORCE INDEX (idx_name) JOIN orders USE INDEX (idx_user) ON orders.user_id = users.id",
+			expected: "SELECT * FROM users FORCE INDEX ( idx_name ) JOIN orders USE INDEX ( idx_user ) ON orders.user_id = users.id",
+			aliases:  map[string]string{},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSMySQL),
+			},
+		},
+		{
+			input:    "SELECT * FROM users u IGNORE INDEX (idx_name)",
+			expected: "SELECT * FROM users u IGNORE INDEX ( idx_name )",
+			aliases:  map[string]string{"u": "users"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSMySQL),
+			},
+		},
+		{
+			input:    "SELECT * FROM [dbo].[users] [u] WITH (NOLOCK)",
+			expected: "SELECT * FROM dbo.users u WITH ( NOLOCK )",
+			aliases:  map[string]string{"u": "dbo.users"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+			},
+		},
+	}
+
+	normalizer := NewNormalizer(
+		WithCollectAliases(true),
+		WithKeepSQLAlias(false),
+	)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got, statementMetadata, err := normalizer.Normalize(test.input, test.lexerOpts...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+			assert.Equal(t, test.aliases, statementMetadata.Aliases)
+		})
+	}
+}
+
+func TestNormalizerCollectStatements(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   string
+		statements []StatementInfo
+		lexerOpts  []lexerOption
+	}{
+		{
+			input:    "SELECT * FROM users",
+			expected: "SELECT * FROM users",
+			statements: []StatementInfo{
+				{
+					Commands:      []string{"SELECT"},
+					Tables:        []string{"users"},
+					Comments:      []string{},
+					Procedures:    []string{},
+					TableAccesses: []TableAccess{{Table: "users", Access: AccessRead, Command: "SELECT"}},
+					Start:         Position{0, 1, 1},
+					End:           Position{19, 1, 20},
+				},
+			},
+		},
+		{
+			input:    "SELECT * FROM a JOIN c ON a.id = c.id; /* cleanup */ DELETE FROM b WHERE id IN (SELECT id FROM a);",
+			expected: "SELECT * FROM a JOIN c ON a.id = c.id; DELETE FROM b WHERE id IN ( SELECT id FROM a )",
+			statements: []StatementInfo{
+				{
+					Commands:   []string{"SELECT", "JOIN"},
+					Tables:     []string{"a", "c"},
+					Comments:   []string{},
+					Procedures: []string{},
+					TableAccesses: []TableAccess{
+						{Table: "a", Access: AccessRead, Command: "SELECT"},
+						{Table: "c", Access: AccessRead, Command: "SELECT"},
+					},
+					Start: Position{0, 1, 1},
+					End:   Position{37, 1, 38},
+				},
+				{
+					Commands:   []string{"DELETE", "SELECT"},
+					Tables:     []string{"b", "a"},
+					Comments:   []string{"/* cleanup */"},
+					Procedures: []string{},
+					TableAccesses: []TableAccess{
+						{Table: "b", Access: AccessWrite, Command: "DELETE"},
+						{Table: "a", Access: AccessRead, Command: "SELECT"},
+					},
+					Start: Position{39, 1, 40},
+					End:   Position{97, 1, 98},
+				},
+			},
+		},
+		{
+			input:    "WITH x AS (SELECT 1) SELECT * FROM x\nGO\nUPDATE x SET a = ?",
+			expected: "WITH x AS ( SELECT 1 ) SELECT * FROM x GO UPDATE x SET a = ?",
+			statements: []StatementInfo{
+				{
+					Commands:   []string{"SELECT"},
+					Tables:     []string{},
+					Comments:   []string{},
+					Procedures: []string{},
+					Start:      Position{0, 1, 1},
+					End:        Position{36, 1, 37},
+				},
+				{
+					Commands:      []string{"UPDATE"},
+					Tables:        []string{"x"},
+					Comments:      []string{},
+					Procedures:    []string{},
+					TableAccesses: []TableAccess{{Table: "x", Access: AccessWrite, Command: "UPDATE"}},
+					Start:         Position{40, 3, 1},
+					End:           Position{58, 3, 19},
+				},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			input:    "CREATE PROCEDURE cleanup AS BEGIN DELETE FROM logs; END\nGO\n-- only a comment\nGO",
+			expected: "CREATE PROCEDURE cleanup AS BEGIN DELETE FROM logs; END GO GO",
+			statements: []StatementInfo{
+				{
+					Commands:      []string{"CREATE", "BEGIN", "DELETE"},
+					Tables:        []string{"logs"},
+					Comments:      []string{},
+					Procedures:    []string{"cleanup"},
+					TableAccesses: []TableAccess{{Table: "logs", Access: AccessWrite, Command: "DELETE"}},
+					Start:         Position{0, 1, 1},
+					End:           Position{55, 1, 56},
+				},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+	}
+
+	normalizers := []*Normalizer{
+		NewNormalizer(
+			WithCollectComments(true),
+			WithCollectCommands(true),
+			WithCollectTables(true),
+			WithCollectStatements(true),
+		),
+		// the statements are collected on their own
+		NewNormalizer(WithCollectStatements(true)),
+	}
+
+	for _, normalizer := range normalizers {
+		for _, test := range tests {
+			t.Run("", func(t *testing.T) {
+				got, statementMetadata, err := normalizer.Normalize(test.input, test.lexerOpts...)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, got)
+				assert.Equal(t, test.statements, statementMetadata.Statements)
+			})
+		}
+	}
+}
+
+func TestNormalizerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		kind     ErrorKind
+	}{
+		{
+			input:    "SELECT * FROM users /* truncated",
+			expected: "SELECT * FROM users /* truncated",
+			kind:     UnterminatedComment,
+		},
+		{
+			input:    `SELECT * FROM "users`,
+			expected: `SELECT * FROM "users`,
+			kind:     UnterminatedQuotedIdentifier,
+		},
+		{
+			input:    "SELECT $tag$abc",
+			expected: "SELECT $tag$abc",
+			kind:     UnterminatedDollarQuotedString,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			normalizer := NewNormalizer()
+			got, _, err := normalizer.Normalize(test.input)
+			assert.Equal(t, test.expected, got)
+			var lexErr *LexError
+			if assert.ErrorAs(t, err, &lexErr) {
+				assert.Equal(t, test.kind, lexErr.Kind)
+			}
+
+			normalizer = NewNormalizer(WithBestEffort(true))
+			got, _, err = normalizer.Normalize(test.input)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
 		})
 	}
 }
@@ -1166,5 +1735,85 @@ func ExampleNormalizer() {
 	fmt.Println(normalizedSQL)
 	fmt.Println(statementMetadata)
 	// Output: SELECT * FROM users WHERE id in ( ? )
-	// &{34 [users] [/* this is a comment */] [SELECT] []}
+	// &{34 [users] [/* this is a comment */] [SELECT] [] [] map[] [] [] []}
+}
+
+func TestNormalizerCollectTableNames(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   string
+		tables     []string
+		tableNames []TableName
+		lexerOpts  []lexerOption
+	}{
+		{
+			input:    "SELECT * FROM users",
+			expected: "SELECT * FROM users",
+			tables:   []string{"users"},
+			tableNames: []TableName{
+				{Name: "users", Original: "users"},
+			},
+		},
+		{
+			input:    `SELECT * FROM public."users" u JOIN "sales"."order.items" i ON i.user_id = u.id`,
+			expected: "SELECT * FROM public.users u JOIN sales.order.items i ON i.user_id = u.id",
+			tables:   []string{"public.users", "sales.order.items"},
+			tableNames: []TableName{
+				{Schema: "public", Name: "users", Original: `public."users"`},
+				{Schema: "sales", Name: "order.items", Original: `"sales"."order.items"`},
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSPostgres),
+			},
+		},
+		{
+			input:    "SELECT * FROM srv.[my db].dbo.[users]",
+			expected: "SELECT * FROM srv.my db.dbo.users",
+			tables:   []string{"srv.my db.dbo.users"},
+			tableNames: []TableName{
+				{Server: "srv", Catalog: "my db", Schema: "dbo", Name: "users", Original: "srv.[my db].dbo.[users]"},
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+			},
+		},
+		{
+			input:    "INSERT INTO shop.`order` SELECT * FROM shop.cart",
+			expected: "INSERT INTO shop.order SELECT * FROM shop.cart",
+			tables:   []string{"shop.order", "shop.cart"},
+			tableNames: []TableName{
+				{Schema: "shop", Name: "order", Original: "shop.`order`"},
+				{Schema: "shop", Name: "cart", Original: "shop.cart"},
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSMySQL),
+			},
+		},
+		{
+			input:    "SELECT * FROM analytics.public.events",
+			expected: "SELECT * FROM analytics.public.events",
+			tables:   []string{"analytics.public.events"},
+			tableNames: []TableName{
+				{Catalog: "analytics", Schema: "public", Name: "events", Original: "analytics.public.events"},
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSnowflake),
+			},
+		},
+	}
+
+	normalizer := NewNormalizer(
+		WithCollectTables(true),
+		WithCollectTableNames(true),
+	)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got, statementMetadata, err := normalizer.Normalize(test.input, test.lexerOpts...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+			assert.Equal(t, test.tables, statementMetadata.Tables)
+			assert.Equal(t, test.tableNames, statementMetadata.TableNames)
+		})
+	}
 }
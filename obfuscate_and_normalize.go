@@ -1,43 +1,64 @@
 package sqllexer
 
-import "strings"
-
 // ObfuscateAndNormalize takes an input SQL string and returns an normalized SQL string with metadata
 // This function is a convenience function that combines the Obfuscator and Normalizer in one pass
+// Lexer errors are reported the same way as in Normalizer.Normalize
+// The literals are replaced with bare placeholders, even if the obfuscator hashes them, see WithLiteralHashKey
 func ObfuscateAndNormalize(input string, obfuscator *Obfuscator, normalizer *Normalizer, lexerOpts ...lexerOption) (normalizedSQL string, statementMetadata *StatementMetadata, err error) {
-	lexer := New(
+	lexer := getLexer(
 		input,
 		lexerOpts...,
 	)
+	defer putLexer(lexer)
 
-	var normalizedSQLBuilder strings.Builder
+	normalizedSQLBuilder := getBuffer()
+	defer putBuffer(normalizedSQLBuilder)
 
-	statementMetadata = &StatementMetadata{
-		Tables:     []string{},
-		Comments:   []string{},
-		Commands:   []string{},
-		Procedures: []string{},
-	}
+	statementMetadata = normalizer.newStatementMetadata()
 
-	var lastToken Token // The last token that is not whitespace or comment
-	var groupablePlaceholder groupablePlaceholder
+	var lastToken keywordToken // The last token that is not whitespace or comment
+	groupablePlaceholder := groupablePlaceholder{obfuscator: obfuscator, placeholders: normalizer.config.Placeholders}
+	state := obfuscation{normalizing: true, lexer: lexer}
 
-	ctes := make(map[string]bool) // Holds the CTEs that are currently being processed
+	metadataContext := getMetadataContext(lexer.config.DBMS)
+	defer putMetadataContext(metadataContext)
+
+	var statements *statementsCollector
+	if normalizer.config.CollectStatements {
+		statements = newStatementsCollector(lexer)
+	}
 
 	for {
-		token := lexer.Scan()
-		if token.Type == EOF {
+		var scanned Token
+		if statements != nil {
+			scanned = statements.scan()
+		} else {
+			scanned = lexer.Scan()
+		}
+		if scanned.Type == EOF {
 			break
 		}
-		token.Value = obfuscator.ObfuscateTokenValue(token, lastToken, lexerOpts...)
-		normalizer.collectMetadata(&token, &lastToken, statementMetadata, ctes)
-		normalizer.normalizeSQL(&token, &lastToken, &normalizedSQLBuilder, &groupablePlaceholder, lexerOpts...)
+		if obfuscated := obfuscator.obfuscateTokenValue(scanned, lastToken.Token, &state, lexerOpts...); obfuscated != scanned.Value {
+			// the keyword is the one of the obfuscated value, e.g. none for an identifier whose digits were replaced
+			scanned.Value, scanned.KeywordID = obfuscated, 0
+		}
+		token := newKeywordToken(scanned)
+		if statements != nil {
+			statements.collect(token)
+		}
+		normalizer.collectMetadata(&token, &lastToken, statementMetadata, metadataContext)
+		normalizer.normalizeSQL(&token, &lastToken, normalizedSQLBuilder, &groupablePlaceholder, metadataContext.dictionary, lexerOpts...)
 	}
 
 	normalizedSQL = normalizedSQLBuilder.String()
 
 	// Dedupe collected metadata
+	metadataContext.resolveColumns(statementMetadata.Columns)
+	metadataContext.collectAliases(statementMetadata.Aliases)
 	dedupeStatementMetadata(statementMetadata)
+	if statements != nil {
+		statementMetadata.Statements = statements.statementInfos()
+	}
 
-	return normalizer.trimNormalizedSQL(normalizedSQL), statementMetadata, nil
+	return normalizer.trimNormalizedSQL(normalizedSQL, metadataContext.terminator), statementMetadata, normalizer.lexerError(lexer)
 }
@@ -436,3 +436,58 @@ multiline comment */
 		})
 	}
 }
+
+func TestObfuscationAndNormalizationErrors(t *testing.T) {
+	obfuscator := NewObfuscator()
+
+	normalizer := NewNormalizer()
+	got, _, err := ObfuscateAndNormalize("SELECT * FROM users WHERE id = 1 /* truncated", obfuscator, normalizer)
+	assert.Equal(t, "SELECT * FROM users WHERE id = ? /* truncated", got)
+	var lexErr *LexError
+	if assert.ErrorAs(t, err, &lexErr) {
+		assert.Equal(t, UnterminatedComment, lexErr.Kind)
+		assert.Equal(t, "/* truncated", lexErr.Partial)
+	}
+
+	normalizer = NewNormalizer(WithBestEffort(true))
+	got, _, err = ObfuscateAndNormalize("SELECT * FROM users WHERE id = 1 /* truncated", obfuscator, normalizer)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id = ? /* truncated", got)
+}
+
+func TestObfuscationAndNormalizationCollectStatements(t *testing.T) {
+	obfuscator := NewObfuscator(
+		WithReplaceDigits(true),
+	)
+
+	normalizer := NewNormalizer(
+		WithCollectCommands(true),
+		WithCollectTables(true),
+		WithCollectStatements(true),
+	)
+
+	got, statementMetadata, err := ObfuscateAndNormalize("SELECT * FROM logs_2024 WHERE id = 1; DELETE FROM users_1 WHERE id = 2", obfuscator, normalizer)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM logs_? WHERE id = ?; DELETE FROM users_? WHERE id = ?", got)
+	assert.Equal(t, []string{"logs_?", "users_?"}, statementMetadata.Tables)
+	assert.Equal(t, []StatementInfo{
+		{
+			Commands:      []string{"SELECT"},
+			Tables:        []string{"logs_?"},
+			Comments:      []string{},
+			Procedures:    []string{},
+			TableAccesses: []TableAccess{{Table: "logs_?", Access: AccessRead, Command: "SELECT"}},
+			Start:         Position{0, 1, 1},
+			End:           Position{36, 1, 37},
+		},
+		{
+			Commands:      []string{"DELETE"},
+			Tables:        []string{"users_?"},
+			Comments:      []string{},
+			Procedures:    []string{},
+			TableAccesses: []TableAccess{{Table: "users_?", Access: AccessWrite, Command: "DELETE"}},
+			Start:         Position{38, 1, 39},
+			End:           Position{70, 1, 71},
+		},
+	}, statementMetadata.Statements)
+}
@@ -1,7 +1,15 @@
 package sqllexer
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type obfuscatorConfig struct {
@@ -11,6 +19,35 @@ type obfuscatorConfig struct {
 	ReplaceBoolean             bool `json:"replace_boolean"`
 	ReplaceNull                bool `json:"replace_null"`
 	KeepJsonPath               bool `json:"keep_json_path"` // by default, we replace json path with placeholder
+
+	// The rules keeping the string and number literals that are harmless, e.g. WHERE status = 'active' or LIMIT 100.
+	// The names are matched regardless of case, and the functions and columns regardless of their qualifiers.
+	KeepLiteralsInFunctions   []string       `json:"keep_literals_in_functions"`   // e.g. DATE_TRUNC for DATE_TRUNC('month', created_at)
+	KeepLiteralsOfColumns     []string       `json:"keep_literals_of_columns"`     // e.g. status for status = 'active' or status IN ('active', 'new')
+	KeepLiteralsAfterKeywords []string       `json:"keep_literals_after_keywords"` // e.g. LIMIT for LIMIT 100 or INTERVAL for INTERVAL '1 day'
+	KeepLiteralsMatching      *regexp.Regexp `json:"-"`                            // matched against the literal as written, e.g. ^'[a-z_]+'$
+
+	// The placeholders that replace each kind of literal, ? when empty.
+	// The digits of the identifiers replaced with ReplaceDigits are always ?, e.g. users? for users123.
+	StringPlaceholder             string `json:"string_placeholder"`
+	NumberPlaceholder             string `json:"number_placeholder"`
+	BooleanPlaceholder            string `json:"boolean_placeholder"`
+	NullPlaceholder               string `json:"null_placeholder"`
+	DollarQuotedStringPlaceholder string `json:"dollar_quoted_string_placeholder"`
+	HexPlaceholder                string `json:"hex_placeholder"`    // e.g. 0x1F and X'1F'
+	BinaryPlaceholder             string `json:"binary_placeholder"` // e.g. 0b0101 and B'0101'
+
+	// NumberedPlaceholders specifies whether each placeholder is followed by its position in the query, starting at 1,
+	// e.g. $1, $2 with the $ placeholder, so that the obfuscated query can be run with bind parameters.
+	// ObfuscateAndNormalize keeps the placeholders bare, as grouping them would leave gaps in the numbering.
+	NumberedPlaceholders bool `json:"numbered_placeholders"`
+
+	// LiteralHashKey is the secret key of the HMAC that follows the placeholder of each string and number literal,
+	// e.g. ?_3fa9c2d1, so that equal values can be told apart from different ones without being revealed.
+	// The literals are not hashed when it is empty. It is never serialized.
+	LiteralHashKey []byte `json:"-"`
+	// LiteralHashLength is the number of hexadecimal digits of the hash that are kept, 8 by default and 64 at most.
+	LiteralHashLength int `json:"literal_hash_length"`
 }
 
 type obfuscatorOption func(*obfuscatorConfig)
@@ -51,8 +88,116 @@ func WithKeepJsonPath(keepJsonPath bool) obfuscatorOption {
 	}
 }
 
+// WithKeepLiteralsInFunctions keeps the literals that are arguments of the functions, e.g. 'month' in DATE_TRUNC('month', created_at).
+func WithKeepLiteralsInFunctions(functions ...string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.KeepLiteralsInFunctions = functions
+	}
+}
+
+// WithKeepLiteralsOfColumns keeps the literals that the columns are compared against,
+// e.g. 'active' in status = 'active' or status IN ('active', 'new').
+func WithKeepLiteralsOfColumns(columns ...string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.KeepLiteralsOfColumns = columns
+	}
+}
+
+// WithKeepLiteralsAfterKeywords keeps the literals that follow the keywords, e.g. 100 in LIMIT 100.
+func WithKeepLiteralsAfterKeywords(keywords ...string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.KeepLiteralsAfterKeywords = keywords
+	}
+}
+
+// WithKeepLiteralsMatching keeps the literals that match the regular expression, as written in the query,
+// e.g. ^'[A-Z]{3}'$ for currency codes.
+func WithKeepLiteralsMatching(pattern *regexp.Regexp) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.KeepLiteralsMatching = pattern
+	}
+}
+
+// WithPlaceholder sets the placeholder of every kind of literal at once, e.g. $ along with WithNumberedPlaceholders.
+func WithPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.StringPlaceholder = placeholder
+		c.NumberPlaceholder = placeholder
+		c.BooleanPlaceholder = placeholder
+		c.NullPlaceholder = placeholder
+		c.DollarQuotedStringPlaceholder = placeholder
+		c.HexPlaceholder = placeholder
+		c.BinaryPlaceholder = placeholder
+	}
+}
+
+func WithStringPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.StringPlaceholder = placeholder
+	}
+}
+
+func WithNumberPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.NumberPlaceholder = placeholder
+	}
+}
+
+func WithBooleanPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.BooleanPlaceholder = placeholder
+	}
+}
+
+func WithNullPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.NullPlaceholder = placeholder
+	}
+}
+
+func WithDollarQuotedStringPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.DollarQuotedStringPlaceholder = placeholder
+	}
+}
+
+func WithHexPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.HexPlaceholder = placeholder
+	}
+}
+
+func WithBinaryPlaceholder(placeholder string) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.BinaryPlaceholder = placeholder
+	}
+}
+
+func WithNumberedPlaceholders(numberedPlaceholders bool) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.NumberedPlaceholders = numberedPlaceholders
+	}
+}
+
+// WithLiteralHashKey makes the obfuscator follow the placeholder of each string and number literal with
+// the keyed hash of its value, e.g. 'alice' becomes ?_3fa9c2d1. Equal values get equal hashes
+// wherever the key is shared, while the values cannot be recovered without it.
+// The normalized SQL of ObfuscateAndNormalize keeps the bare placeholders, so that it does not vary with the values.
+func WithLiteralHashKey(key []byte) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.LiteralHashKey = append([]byte(nil), key...)
+	}
+}
+
+func WithLiteralHashLength(length int) obfuscatorOption {
+	return func(c *obfuscatorConfig) {
+		c.LiteralHashLength = length
+	}
+}
+
 type Obfuscator struct {
 	config *obfuscatorConfig
+	macs   sync.Pool // the HMACs hashing the literals, see WithLiteralHashKey
 }
 
 func NewObfuscator(opts ...obfuscatorOption) *Obfuscator {
@@ -67,20 +212,107 @@ func NewObfuscator(opts ...obfuscatorOption) *Obfuscator {
 	return obfuscator
 }
 
+// The default placeholders, see WithPlaceholder to change them.
 const (
 	StringPlaceholder = "?"
 	NumberPlaceholder = "?"
 )
 
+// Literal is a value of the query that the obfuscator replaced with a placeholder.
+type Literal struct {
+	Value string `json:"value"` // the value as written in the query, e.g. 'it''s' with its quotes
+	// Arg is the value as an argument of the parameterized query: a string for the strings, without their quotes
+	// and escapes, e.g. it's for 'it''s', see Lexer.Unquote, a bool for the booleans and nil for NULL.
+	// The other values are strings as written in the query, e.g. 1.5e3 for the number 1.5e3.
+	Arg   any       `json:"arg"`
+	Type  TokenType `json:"type"`  // the type of the token of the value, e.g. STRING or NUMBER
+	Start Position  `json:"start"` // position of the first character of the value in the query
+	End   Position  `json:"end"`   // position immediately after the last character of the value
+	// Placeholder is the position of the placeholder replacing the value in the obfuscated query, starting at 1,
+	// e.g. 2 for $2 with numbered placeholders.
+	Placeholder int `json:"placeholder"`
+}
+
+// obfuscation holds the state of the obfuscation of a single query.
+type obfuscation struct {
+	placeholders    int       // the number of placeholders written so far, see WithNumberedPlaceholders
+	normalizing     bool      // whether the obfuscated query is normalized, which keeps the placeholders bare
+	collectLiterals bool      // whether the replaced values are collected in literals, see ObfuscateWithLiterals
+	literals        []Literal // the values replaced so far
+	lexer           *Lexer    // the lexer of the query, which gives the positions and the keywords of the tokens
+
+	// The surroundings of the literals, tracked for the keep rules, see WithKeepLiteralsInFunctions and WithKeepLiteralsOfColumns.
+	column string         // the column the next literal is compared against
+	scopes []literalScope // the parentheses around the current token, innermost last
+}
+
+// literalScope holds the surroundings of the literals inside a pair of parentheses.
+type literalScope struct {
+	function string // the function the literals are arguments of, e.g. DATE_TRUNC
+	column   string // the column the literals are compared against, e.g. status for status IN (...)
+}
+
+// track updates the surroundings of the literals with the token, given the last token that is not whitespace.
+func (s *obfuscation) track(token *Token, lastToken *Token) {
+	lastKeyword := word(lastToken)
+	switch {
+	case lastToken.Type == IDENT && lastKeyword == "" || lastToken.Type == QUOTED_IDENT:
+		s.column = lastToken.Value
+	case lastKeyword == "NOT" || lastKeyword == "IN" || isComparison(lastToken):
+		// e.g. status NOT IN (...)
+	default:
+		s.column = ""
+	}
+
+	if token.Type != PUNCTUATION {
+		return
+	}
+	switch token.Value {
+	case "(":
+		scope := literalScope{}
+		if lastToken.Type == FUNCTION {
+			scope.function = lastToken.Value
+		}
+		if lastKeyword == "IN" {
+			scope.column = s.column
+		}
+		s.scopes = append(s.scopes, scope)
+	case ")":
+		if len(s.scopes) > 0 {
+			s.scopes = s.scopes[:len(s.scopes)-1]
+		}
+	}
+}
+
 // Obfuscate takes an input SQL string and returns an obfuscated SQL string.
 // The obfuscator replaces all literal values with a single placeholder
 func (o *Obfuscator) Obfuscate(input string, lexerOpts ...lexerOption) string {
-	var obfuscatedSQL strings.Builder
+	return o.obfuscate(input, &obfuscation{}, lexerOpts...)
+}
+
+// ObfuscateWithLiterals obfuscates the input SQL string the same way as Obfuscate,
+// and also returns the values it replaced, in the order of their placeholders.
+// Along with WithNumberedPlaceholders, the obfuscated query and the values make a parameterized query and its arguments,
+// the latter given by Literal.Arg, e.g. alice for the string 'alice'.
+func (o *Obfuscator) ObfuscateWithLiterals(input string, lexerOpts ...lexerOption) (string, []Literal) {
+	state := obfuscation{collectLiterals: true}
+	obfuscatedSQL := o.obfuscate(input, &state, lexerOpts...)
+	return obfuscatedSQL, state.literals
+}
 
-	lexer := New(
+func (o *Obfuscator) obfuscate(input string, state *obfuscation, lexerOpts ...lexerOption) string {
+	obfuscatedSQL := getBuffer()
+	defer putBuffer(obfuscatedSQL)
+
+	lexer := getLexer(
 		input,
 		lexerOpts...,
 	)
+	defer putLexer(lexer)
+
+	// the lexer of a query is replaced by the one of a function body while the body is obfuscated
+	defer func(outer *Lexer) { state.lexer = outer }(state.lexer)
+	state.lexer = lexer
 
 	var lastToken Token // The last token that is not whitespace or comment
 
@@ -89,7 +321,7 @@ func (o *Obfuscator) Obfuscate(input string, lexerOpts ...lexerOption) string {
 		if token.Type == EOF {
 			break
 		}
-		obfuscatedSQL.WriteString(o.ObfuscateTokenValue(token, lastToken, lexerOpts...))
+		obfuscatedSQL.WriteString(o.obfuscateTokenValue(token, lastToken, state, lexerOpts...))
 		if token.Type != WS {
 			lastToken = token
 		}
@@ -98,42 +330,126 @@ func (o *Obfuscator) Obfuscate(input string, lexerOpts ...lexerOption) string {
 	return strings.TrimSpace(obfuscatedSQL.String())
 }
 
+// ObfuscateTo reads the SQL from r and writes it obfuscated to w, the same way as Obfuscate.
+// The output is written as the tokens are scanned, so that large inputs, e.g. SQL dumps or query logs,
+// are obfuscated without holding them in memory. See ReaderLexer for the tokens that are still held whole.
+// It returns the first error returned by r or w.
+func (o *Obfuscator) ObfuscateTo(w io.Writer, r io.Reader, lexerOpts ...lexerOption) error {
+	lexer := NewReaderLexer(r, lexerOpts...)
+	obfuscatedSQL := newTrimmingWriter(w, "")
+
+	var lastToken Token // The last token that is not whitespace or comment
+	state := obfuscation{lexer: lexer.lexer}
+
+	for {
+		token := lexer.Scan()
+		if token.Type == EOF {
+			break
+		}
+		obfuscatedSQL.WriteString(o.obfuscateTokenValue(token, lastToken, &state, lexerOpts...))
+		if token.Type != WS {
+			lastToken = token
+		}
+	}
+
+	if lexer.err != nil {
+		return lexer.err
+	}
+	return obfuscatedSQL.Close()
+}
+
+// ObfuscateTokenValue returns the obfuscated value of the token, given the last token that is not whitespace.
+// The placeholders it returns are not numbered, as their numbering spans the whole query, see WithNumberedPlaceholders.
 func (o *Obfuscator) ObfuscateTokenValue(token Token, lastToken Token, lexerOpts ...lexerOption) string {
+	return o.obfuscateTokenValue(token, lastToken, nil, lexerOpts...)
+}
+
+func (o *Obfuscator) obfuscateTokenValue(token Token, lastToken Token, state *obfuscation, lexerOpts ...lexerOption) string {
+	if state != nil && token.Type != WS && (len(o.config.KeepLiteralsInFunctions) > 0 || len(o.config.KeepLiteralsOfColumns) > 0) {
+		state.track(&token, &lastToken)
+	}
+
 	switch token.Type {
 	case NUMBER:
 		if o.config.KeepJsonPath && isJsonOperator(&lastToken) {
 			return token.Value
 		}
-		return NumberPlaceholder
+		if o.keepLiteral(&token, &lastToken, state) {
+			return token.Value
+		}
+		switch {
+		case isHexNumber(token.Value):
+			return o.literalPlaceholder(o.config.HexPlaceholder, &token, state)
+		case isBinaryNumber(token.Value):
+			return o.literalPlaceholder(o.config.BinaryPlaceholder, &token, state)
+		}
+		return o.literalPlaceholder(o.config.NumberPlaceholder, &token, state)
 	case DOLLAR_QUOTED_FUNCTION:
 		if o.config.DollarQuotedFunc {
 			// obfuscate the content of dollar quoted function
 			quotedFunc := token.Value[6 : len(token.Value)-6] // remove the $func$ prefix and suffix
 			var obfuscatedDollarQuotedFunc strings.Builder
 			obfuscatedDollarQuotedFunc.WriteString("$func$")
-			obfuscatedDollarQuotedFunc.WriteString(o.Obfuscate(quotedFunc, lexerOpts...))
+			if state == nil {
+				state = &obfuscation{}
+			}
+			collected := len(state.literals)
+			var origin Position
+			if state.collectLiterals {
+				origin = state.lexer.Position(token.Offset + len("$func$"))
+			}
+			obfuscatedDollarQuotedFunc.WriteString(o.obfuscate(quotedFunc, state, lexerOpts...))
+			// the positions of the values of the function are relative to its content
+			for i := collected; i < len(state.literals); i++ {
+				state.literals[i].Start = state.literals[i].Start.from(origin)
+				state.literals[i].End = state.literals[i].End.from(origin)
+			}
 			obfuscatedDollarQuotedFunc.WriteString("$func$")
 			return obfuscatedDollarQuotedFunc.String()
 		} else {
-			return StringPlaceholder
+			return o.placeholder(o.config.DollarQuotedStringPlaceholder, &token, state)
 		}
-	case STRING, INCOMPLETE_STRING, DOLLAR_QUOTED_STRING:
+	case STRING:
 		if o.config.KeepJsonPath && isJsonOperator(&lastToken) {
 			return token.Value
 		}
-		return StringPlaceholder
+		if o.keepLiteral(&token, &lastToken, state) {
+			return token.Value
+		}
+		prefix := stringLiteralPrefix(token.Value)
+		switch {
+		case strings.ContainsAny(prefix, "xX"):
+			return o.literalPlaceholder(o.config.HexPlaceholder, &token, state)
+		case strings.ContainsAny(prefix, "bB") && !strings.ContainsAny(prefix, dialectOf(state, lexerOpts).BytesPrefixes):
+			return o.literalPlaceholder(o.config.BinaryPlaceholder, &token, state)
+		}
+		return o.literalPlaceholder(o.config.StringPlaceholder, &token, state)
+	case INCOMPLETE_STRING:
+		if o.config.KeepJsonPath && isJsonOperator(&lastToken) {
+			return token.Value
+		}
+		// the value is cut, its hash would not match the one of the complete value
+		return o.placeholder(o.config.StringPlaceholder, &token, state)
+	case DOLLAR_QUOTED_STRING:
+		if o.config.KeepJsonPath && isJsonOperator(&lastToken) {
+			return token.Value
+		}
+		if o.keepLiteral(&token, &lastToken, state) {
+			return token.Value
+		}
+		return o.placeholder(o.config.DollarQuotedStringPlaceholder, &token, state)
 	case POSITIONAL_PARAMETER:
 		if o.config.ReplacePositionalParameter {
-			return StringPlaceholder
+			return o.placeholder(o.config.StringPlaceholder, &token, state)
 		} else {
 			return token.Value
 		}
 	case IDENT, QUOTED_IDENT:
 		if o.config.ReplaceBoolean && isBoolean(token.Value) {
-			return StringPlaceholder
+			return o.placeholder(o.config.BooleanPlaceholder, &token, state)
 		}
 		if o.config.ReplaceNull && isNull(token.Value) {
-			return StringPlaceholder
+			return o.placeholder(o.config.NullPlaceholder, &token, state)
 		}
 
 		if o.config.ReplaceDigits {
@@ -141,7 +457,183 @@ func (o *Obfuscator) ObfuscateTokenValue(token Token, lastToken Token, lexerOpts
 		} else {
 			return token.Value
 		}
+	case CLIENT_COMMAND:
+		return o.obfuscateClientCommand(&token, state)
+	case FORMAT_DATA:
+		// the data of an INSERT ... FORMAT statement is dropped rather than obfuscated
+		return ""
 	default:
 		return token.Value
 	}
 }
+
+// dialectOf returns the dialect of the query being obfuscated, the one of the lexer options when there is no state.
+func dialectOf(state *obfuscation, lexerOpts []lexerOption) *Dialect {
+	if state != nil && state.lexer != nil {
+		return &state.lexer.dialect.Dialect
+	}
+	var config LexerConfig
+	for _, opt := range lexerOpts {
+		opt(&config)
+	}
+	return &dialectFor(config.DBMS).Dialect
+}
+
+// keepLiteral reports whether the literal is kept by one of the keep rules, given the last token that is not whitespace.
+// The rules on functions and columns need the surroundings of the literal, they do not apply when the state is nil.
+func (o *Obfuscator) keepLiteral(token *Token, lastToken *Token, state *obfuscation) bool {
+	if o.config.KeepLiteralsMatching != nil && o.config.KeepLiteralsMatching.MatchString(token.Value) {
+		return true
+	}
+	if lastToken.Type == IDENT && containsFold(o.config.KeepLiteralsAfterKeywords, lastToken.Value) {
+		return true
+	}
+	if state == nil {
+		return false
+	}
+	var scope literalScope
+	if len(state.scopes) > 0 && lastToken.Type == PUNCTUATION && (lastToken.Value == "(" || lastToken.Value == ",") {
+		scope = state.scopes[len(state.scopes)-1]
+	}
+	if scope.function != "" && containsFold(o.config.KeepLiteralsInFunctions, identifierName(scope.function)) {
+		return true
+	}
+	column := scope.column
+	if isComparison(lastToken) {
+		column = state.column
+	}
+	return column != "" && containsFold(o.config.KeepLiteralsOfColumns, identifierName(column))
+}
+
+// containsFold reports whether the names contain the name, regardless of case.
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder returns the placeholder replacing the value of the token, ? if it is empty,
+// numbered if the obfuscator is configured to.
+func (o *Obfuscator) placeholder(placeholder string, token *Token, state *obfuscation) string {
+	if placeholder == "" {
+		placeholder = StringPlaceholder
+	}
+	if state == nil {
+		return placeholder
+	}
+	state.placeholders++
+	if state.collectLiterals {
+		state.literals = append(state.literals, Literal{
+			Value:       token.Value,
+			Arg:         literalArg(state.lexer, token),
+			Type:        token.Type,
+			Start:       state.lexer.Position(token.Offset),
+			End:         state.lexer.Position(token.Offset + len(token.Value)),
+			Placeholder: state.placeholders,
+		})
+	}
+	if !o.config.NumberedPlaceholders || state.normalizing {
+		return placeholder
+	}
+	return placeholder + strconv.Itoa(state.placeholders)
+}
+
+// literalArg returns the value of the literal as an argument of the parameterized query, see Literal.Arg.
+func literalArg(lexer *Lexer, token *Token) any {
+	if value, ok := lexer.Unquote(*token); ok {
+		return value
+	}
+	if token.Type == IDENT || token.Type == QUOTED_IDENT {
+		switch {
+		case isBoolean(token.Value):
+			return strings.EqualFold(token.Value, "TRUE")
+		case isNull(token.Value):
+			return nil
+		}
+	}
+	return token.Value
+}
+
+// literalPlaceholder returns the placeholder replacing a string or number literal,
+// followed by the keyed hash of the literal as written if the obfuscator hashes the literals.
+func (o *Obfuscator) literalPlaceholder(placeholder string, token *Token, state *obfuscation) string {
+	placeholder = o.placeholder(placeholder, token, state)
+	if len(o.config.LiteralHashKey) == 0 || state != nil && state.normalizing {
+		return placeholder
+	}
+	return placeholder + literalHashSeparator + o.hashLiteral(token.Value)
+}
+
+// literalHashSeparator separates the placeholder from the hash of the literal.
+// It is not # as it starts a comment in MySQL, BigQuery and ClickHouse.
+const literalHashSeparator = "_"
+
+// defaultLiteralHashLength is the number of hexadecimal digits of the literal hashes, unless set with WithLiteralHashLength.
+const defaultLiteralHashLength = 8
+
+// hashLiteral returns the HMAC-SHA256 of the literal, hex encoded and truncated to the configured length.
+func (o *Obfuscator) hashLiteral(literal string) string {
+	mac, _ := o.macs.Get().(hash.Hash)
+	if mac == nil {
+		mac = hmac.New(sha256.New, o.config.LiteralHashKey)
+	}
+	defer o.macs.Put(mac)
+
+	mac.Reset()
+	io.WriteString(mac, literal)
+	var sum [sha256.Size]byte
+	var encoded [2 * sha256.Size]byte
+	hex.Encode(encoded[:], mac.Sum(sum[:0]))
+
+	length := o.config.LiteralHashLength
+	if length <= 0 {
+		length = defaultLiteralHashLength
+	}
+	return string(encoded[:minInt(length, len(encoded))])
+}
+
+// isPlaceholder reports whether the value is one of the placeholders the obfuscator writes, e.g. ?str or $2.
+func (o *Obfuscator) isPlaceholder(value string) bool {
+	for _, placeholder := range [...]string{
+		o.config.StringPlaceholder,
+		o.config.NumberPlaceholder,
+		o.config.BooleanPlaceholder,
+		o.config.NullPlaceholder,
+		o.config.DollarQuotedStringPlaceholder,
+		o.config.HexPlaceholder,
+		o.config.BinaryPlaceholder,
+	} {
+		if placeholder == "" {
+			placeholder = StringPlaceholder
+		}
+		if isPlaceholderOf(value, placeholder, o.config.NumberedPlaceholders) {
+			return true
+		}
+	}
+	return false
+}
+
+// obfuscateClientCommand replaces the password of the client commands that log on,
+// e.g. teradata BTEQ .LOGON tdpid/user,password becomes .LOGON tdpid/user,?
+func (o *Obfuscator) obfuscateClientCommand(token *Token, state *obfuscation) string {
+	command := token.Value
+	name := command[1:]
+	if space := strings.IndexAny(name, " \t"); space >= 0 {
+		name = name[:space]
+	}
+	if !strings.EqualFold(name, "LOGON") && !strings.EqualFold(name, "LOGDATA") {
+		return command
+	}
+	comma := strings.IndexByte(command, ',')
+	if comma < 0 {
+		// the password is prompted for
+		return command
+	}
+	password := *token
+	password.Value = command[comma+1:]
+	password.Offset += comma + 1
+	return command[:comma+1] + o.placeholder(o.config.StringPlaceholder, &password, state)
+}
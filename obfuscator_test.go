@@ -1,7 +1,12 @@
 package sqllexer
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -535,6 +540,22 @@ func TestObfuscator(t *testing.T) {
 			expected:     `SELECT * FROM users where data::jsonb ->> 1`,
 			keepJsonPath: true,
 		},
+		{
+			input:        `SELECT arrayMap(x -> 'secret', names) FROM users`,
+			expected:     `SELECT arrayMap(x -> ?, names) FROM users`,
+			keepJsonPath: true,
+			dbms:         DBMSClickHouse,
+		},
+		{
+			input:    "INSERT INTO users FORMAT JSONEachRow\n{\"name\": \"alice\", \"password\": \"hunter2\"}",
+			expected: `INSERT INTO users FORMAT JSONEachRow`,
+			dbms:     DBMSClickHouse,
+		},
+		{
+			input:    ".LOGON tdprod/etl_user,S3cret!,'acct'\n.SET WIDTH 200\nSEL * FROM users WHERE id = 1",
+			expected: ".LOGON tdprod/etl_user,?\n.SET WIDTH 200\nSEL * FROM users WHERE id = ?",
+			dbms:     DBMSTeradata,
+		},
 	}
 
 	for _, tt := range tests {
@@ -549,8 +570,346 @@ func TestObfuscator(t *testing.T) {
 			)
 			got := obfuscator.Obfuscate(tt.input, WithDBMS(tt.dbms))
 			assert.Equal(t, tt.expected, got)
+
+			var streamed strings.Builder
+			err := obfuscator.ObfuscateTo(&streamed, strings.NewReader(tt.input), WithDBMS(tt.dbms))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, streamed.String())
+		})
+	}
+}
+
+func TestObfuscatorPlaceholders(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		opts     []obfuscatorOption
+		dbms     DBMSType
+	}{
+		{
+			input:    "SELECT * FROM users WHERE name = 'alice' AND age = 30 AND active = true AND deleted_at IS NULL",
+			expected: "SELECT * FROM users WHERE name = ?str AND age = ?num AND active = ?bool AND deleted_at IS ?null",
+			opts: []obfuscatorOption{
+				WithReplaceBoolean(true),
+				WithReplaceNull(true),
+				WithStringPlaceholder("?str"),
+				WithNumberPlaceholder("?num"),
+				WithBooleanPlaceholder("?bool"),
+				WithNullPlaceholder("?null"),
+			},
+		},
+		{
+			input:    "SELECT 0x1F, X'CAFE', 0b0101, B'0101', 42, 'text'",
+			expected: "SELECT ?hex, ?hex, ?bin, ?bin, ?, ?",
+			opts:     []obfuscatorOption{WithHexPlaceholder("?hex"), WithBinaryPlaceholder("?bin")},
+		},
+		{
+			// BigQuery b prefixes a bytes string rather than a bit string
+			input:    `SELECT b'\xff', rb'\n', B"abc", 0x1F, 'text'`,
+			expected: "SELECT ?, ?, ?, ?hex, ?",
+			opts:     []obfuscatorOption{WithHexPlaceholder("?hex"), WithBinaryPlaceholder("?bin")},
+			dbms:     DBMSBigQuery,
+		},
+		{
+			// the digits of the identifiers are always replaced with ?, never with the number placeholder
+			input:    "SELECT * FROM users_2024 WHERE id = 1",
+			expected: "SELECT * FROM users_? WHERE id = ?num1",
+			opts:     []obfuscatorOption{WithNumberPlaceholder("?num"), WithNumberedPlaceholders(true), WithReplaceDigits(true)},
+		},
+		{
+			input:    "SELECT $tag$secret$tag$, 'text'",
+			expected: "SELECT ?dq, ?",
+			opts:     []obfuscatorOption{WithDollarQuotedStringPlaceholder("?dq")},
+		},
+		{
+			input:    "SELECT * FROM users WHERE id IN (1, 2) AND name = 'alice' AND id = $1",
+			expected: "SELECT * FROM users WHERE id IN ($1, $2) AND name = $3 AND id = $4",
+			opts:     []obfuscatorOption{WithPlaceholder("$"), WithNumberedPlaceholders(true), WithReplacePositionalParameter(true)},
+		},
+		{
+			input:    "SELECT * FROM users123 WHERE id = 1",
+			expected: "SELECT * FROM users? WHERE id = $1",
+			opts:     []obfuscatorOption{WithPlaceholder("$"), WithNumberedPlaceholders(true), WithReplaceDigits(true)},
+		},
+		{
+			// a doubled quote does not end the string, so it is numbered once
+			input:    "SELECT * FROM users WHERE name = 'it''s' AND id = 1",
+			expected: "SELECT * FROM users WHERE name = $1 AND id = $2",
+			opts:     []obfuscatorOption{WithPlaceholder("$"), WithNumberedPlaceholders(true)},
+		},
+		{
+			// the numbering goes on inside the dollar quoted functions
+			input:    "SELECT 1, $func$SELECT 'a', 2$func$",
+			expected: "SELECT ?1, $func$SELECT ?2, ?3$func$",
+			opts:     []obfuscatorOption{WithNumberedPlaceholders(true), WithDollarQuotedFunc(true)},
+		},
+		{
+			input:    ".LOGON host/user,secret\nSEL 1",
+			expected: ".LOGON host/user,$1\nSEL $2",
+			opts:     []obfuscatorOption{WithPlaceholder("$"), WithNumberedPlaceholders(true)},
+			dbms:     DBMSTeradata,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			obfuscator := NewObfuscator(tt.opts...)
+			assert.Equal(t, tt.expected, obfuscator.Obfuscate(tt.input, WithDBMS(tt.dbms)))
 		})
 	}
+
+	obfuscator := NewObfuscator(WithBinaryPlaceholder("?bin"))
+	assert.Equal(t, "?", obfuscator.ObfuscateTokenValue(Token{Type: STRING, Value: "b'abc'"}, Token{}, WithDBMS(DBMSBigQuery)))
+	assert.Equal(t, "?bin", obfuscator.ObfuscateTokenValue(Token{Type: STRING, Value: "b'0101'"}, Token{}, WithDBMS(DBMSPostgres)))
+}
+
+func TestObfuscatorPlaceholdersGrouping(t *testing.T) {
+	obfuscator := NewObfuscator(WithPlaceholder("$"), WithNumberedPlaceholders(true))
+	normalizer := NewNormalizer()
+	got, _, err := ObfuscateAndNormalize("SELECT * FROM users WHERE id IN (1, 2, 3) AND tags = ARRAY['a', 'b'] AND name = 'alice'", obfuscator, normalizer)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id IN ( $ ) AND tags = ARRAY [ $ ] AND name = $", got)
+
+	// the normalized placeholders are bare, so that the lists of any length give the same query
+	for _, query := range []string{
+		"SELECT * FROM users WHERE a IN (1, 2) AND b = 'x'",
+		"SELECT * FROM users WHERE a IN (1, 2, 3, 4) AND b = 'x'",
+	} {
+		got, _, err = ObfuscateAndNormalize(query, obfuscator, normalizer)
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM users WHERE a IN ( $ ) AND b = $", got)
+	}
+
+	obfuscator = NewObfuscator(WithStringPlaceholder("?str"), WithNumberPlaceholder("?num"))
+	got, _, err = ObfuscateAndNormalize("SELECT * FROM users WHERE id IN (1, 2, 3) AND name IN ('a', 'b')", obfuscator, normalizer)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id IN ( ?num ) AND name IN ( ?str )", got)
+
+	// the placeholders the obfuscator does not write are not grouped
+	got, _, err = ObfuscateAndNormalize("SELECT * FROM users WHERE id IN (a, b)", obfuscator, normalizer)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id IN ( a, b )", got)
+}
+
+func TestObfuscatorLiteralHashing(t *testing.T) {
+	query := "SELECT * FROM users WHERE a = 'alice' AND b = 'alice' AND c = 'bob' AND d = 42 AND e = 'trunc"
+	hashOf := func(key string, literal string, length int) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(literal))
+		return hex.EncodeToString(mac.Sum(nil))[:length]
+	}
+
+	obfuscator := NewObfuscator(WithLiteralHashKey([]byte("secret")))
+	got := obfuscator.Obfuscate(query)
+	assert.Equal(t, fmt.Sprintf("SELECT * FROM users WHERE a = ?_%s AND b = ?_%s AND c = ?_%s AND d = ?_%s AND e = ?",
+		hashOf("secret", "'alice'", 8), hashOf("secret", "'alice'", 8), hashOf("secret", "'bob'", 8), hashOf("secret", "42", 8)), got)
+
+	// the hashes are no comments to the DBMS that start them with #
+	for _, token := range New(got, WithDBMS(DBMSMySQL)).ScanAll() {
+		assert.NotEqual(t, COMMENT, token.Type)
+	}
+
+	// the same key gives the same hashes, another key other ones
+	assert.Equal(t, got, NewObfuscator(WithLiteralHashKey([]byte("secret"))).Obfuscate(query))
+	assert.NotEqual(t, got, NewObfuscator(WithLiteralHashKey([]byte("other"))).Obfuscate(query))
+
+	obfuscator = NewObfuscator(WithLiteralHashKey([]byte("secret")), WithLiteralHashLength(4), WithStringPlaceholder("?str"))
+	assert.Equal(t, "SELECT ?str_"+hashOf("secret", "'alice'", 4), obfuscator.Obfuscate("SELECT 'alice'"))
+
+	obfuscator = NewObfuscator(WithLiteralHashKey([]byte("secret")), WithLiteralHashLength(100))
+	assert.Equal(t, "SELECT ?_"+hashOf("secret", "'alice'", 64), obfuscator.Obfuscate("SELECT 'alice'"))
+
+	// the normalized SQL keeps the bare placeholders
+	normalized, _, err := ObfuscateAndNormalize(query, obfuscator, NewNormalizer())
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE a = ? AND b = ? AND c = ? AND d = ? AND e = ?", normalized)
+}
+
+func TestObfuscatorWithLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		literals []Literal
+		opts     []obfuscatorOption
+		dbms     DBMSType
+	}{
+		{
+			name:     "strings, numbers, booleans and nulls",
+			input:    "SELECT * FROM users WHERE name = 'alice' AND age > 30 AND active = true AND deleted_at IS NULL",
+			expected: "SELECT * FROM users WHERE name = $1 AND age > $2 AND active = $3 AND deleted_at IS $4",
+			literals: []Literal{
+				{Value: "'alice'", Arg: "alice", Type: STRING, Start: Position{Offset: 33, Line: 1, Column: 34}, End: Position{Offset: 40, Line: 1, Column: 41}, Placeholder: 1},
+				{Value: "30", Arg: "30", Type: NUMBER, Start: Position{Offset: 51, Line: 1, Column: 52}, End: Position{Offset: 53, Line: 1, Column: 54}, Placeholder: 2},
+				{Value: "true", Arg: true, Type: IDENT, Start: Position{Offset: 67, Line: 1, Column: 68}, End: Position{Offset: 71, Line: 1, Column: 72}, Placeholder: 3},
+				{Value: "NULL", Arg: nil, Type: IDENT, Start: Position{Offset: 90, Line: 1, Column: 91}, End: Position{Offset: 94, Line: 1, Column: 95}, Placeholder: 4},
+			},
+			opts: []obfuscatorOption{WithPlaceholder("$"), WithNumberedPlaceholders(true), WithReplaceBoolean(true), WithReplaceNull(true)},
+		},
+		{
+			name:     "kept json path",
+			input:    "SELECT data->'name', 'é'\nFROM t",
+			expected: "SELECT data->'name', ?\nFROM t",
+			literals: []Literal{
+				{Value: "'é'", Arg: "é", Type: STRING, Start: Position{Offset: 21, Line: 1, Column: 22}, End: Position{Offset: 25, Line: 1, Column: 25}, Placeholder: 1},
+			},
+			opts: []obfuscatorOption{WithKeepJsonPath(true)},
+			dbms: DBMSPostgres,
+		},
+		{
+			name:     "dollar quoted function",
+			input:    "SELECT 1,\n  $func$SELECT 'a',\n2$func$",
+			expected: "SELECT ?,\n  $func$SELECT ?,\n?$func$",
+			literals: []Literal{
+				{Value: "1", Arg: "1", Type: NUMBER, Start: Position{Offset: 7, Line: 1, Column: 8}, End: Position{Offset: 8, Line: 1, Column: 9}, Placeholder: 1},
+				{Value: "'a'", Arg: "a", Type: STRING, Start: Position{Offset: 25, Line: 2, Column: 16}, End: Position{Offset: 28, Line: 2, Column: 19}, Placeholder: 2},
+				{Value: "2", Arg: "2", Type: NUMBER, Start: Position{Offset: 30, Line: 3, Column: 1}, End: Position{Offset: 31, Line: 3, Column: 2}, Placeholder: 3},
+			},
+			opts: []obfuscatorOption{WithDollarQuotedFunc(true)},
+			dbms: DBMSPostgres,
+		},
+		{
+			name:     "logon password",
+			input:    "SEL 1;\n.LOGON host/user,password",
+			expected: "SEL ?;\n.LOGON host/user,?",
+			literals: []Literal{
+				{Value: "1", Arg: "1", Type: NUMBER, Start: Position{Offset: 4, Line: 1, Column: 5}, End: Position{Offset: 5, Line: 1, Column: 6}, Placeholder: 1},
+				{Value: "password", Arg: "password", Type: CLIENT_COMMAND, Start: Position{Offset: 24, Line: 2, Column: 18}, End: Position{Offset: 32, Line: 2, Column: 26}, Placeholder: 2},
+			},
+			dbms: DBMSTeradata,
+		},
+		{
+			name:     "escaped strings",
+			input:    "SELECT 'it\\'s', 'say ''hi''', 'a\\nb', X'0A'",
+			expected: "SELECT ?, ?, ?, ?",
+			literals: []Literal{
+				{Value: "'it\\'s'", Arg: "it's", Type: STRING, Start: Position{Offset: 7, Line: 1, Column: 8}, End: Position{Offset: 14, Line: 1, Column: 15}, Placeholder: 1},
+				{Value: "'say ''hi'''", Arg: "say 'hi'", Type: STRING, Start: Position{Offset: 16, Line: 1, Column: 17}, End: Position{Offset: 28, Line: 1, Column: 29}, Placeholder: 2},
+				{Value: "'a\\nb'", Arg: "a\nb", Type: STRING, Start: Position{Offset: 30, Line: 1, Column: 31}, End: Position{Offset: 36, Line: 1, Column: 37}, Placeholder: 3},
+				{Value: "X'0A'", Arg: "0A", Type: STRING, Start: Position{Offset: 38, Line: 1, Column: 39}, End: Position{Offset: 43, Line: 1, Column: 44}, Placeholder: 4},
+			},
+			dbms: DBMSMySQL,
+		},
+		{
+			name:     "doubled quotes and dollar quoted strings",
+			input:    "SELECT 'it''s', $$a 'b'$$",
+			expected: "SELECT ?, ?",
+			literals: []Literal{
+				{Value: "'it''s'", Arg: "it's", Type: STRING, Start: Position{Offset: 7, Line: 1, Column: 8}, End: Position{Offset: 14, Line: 1, Column: 15}, Placeholder: 1},
+				{Value: "$$a 'b'$$", Arg: "a 'b'", Type: DOLLAR_QUOTED_STRING, Start: Position{Offset: 16, Line: 1, Column: 17}, End: Position{Offset: 25, Line: 1, Column: 26}, Placeholder: 2},
+			},
+			dbms: DBMSPostgres,
+		},
+		{
+			name:     "no literals",
+			input:    "SELECT id FROM users",
+			expected: "SELECT id FROM users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obfuscator := NewObfuscator(tt.opts...)
+			got, literals := obfuscator.ObfuscateWithLiterals(tt.input, WithDBMS(tt.dbms))
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, tt.literals, literals)
+			assert.Equal(t, obfuscator.Obfuscate(tt.input, WithDBMS(tt.dbms)), got)
+			for _, literal := range literals {
+				assert.Equal(t, literal.Value, tt.input[literal.Start.Offset:literal.End.Offset])
+			}
+		})
+	}
+}
+
+func ExampleObfuscator_ObfuscateWithLiterals() {
+	obfuscator := NewObfuscator(WithPlaceholder("$"), WithNumberedPlaceholders(true))
+	obfuscated, literals := obfuscator.ObfuscateWithLiterals("SELECT * FROM users WHERE name = 'alice' AND age > 30")
+	fmt.Println(obfuscated)
+	for _, literal := range literals {
+		fmt.Printf("$%d = %v\n", literal.Placeholder, literal.Arg)
+	}
+	// Output:
+	// SELECT * FROM users WHERE name = $1 AND age > $2
+	// $1 = alice
+	// $2 = 30
+}
+
+func TestObfuscatorKeepLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		opts     []obfuscatorOption
+	}{
+		{
+			name:     "function arguments",
+			input:    "SELECT DATE_TRUNC('month', created_at), pg_catalog.date_part('year', d), ROUND(price, 2), LOWER('Alice') FROM t",
+			expected: "SELECT DATE_TRUNC('month', created_at), pg_catalog.date_part('year', d), ROUND(price, ?), LOWER(?) FROM t",
+			opts:     []obfuscatorOption{WithKeepLiteralsInFunctions("date_trunc", "DATE_PART")},
+		},
+		{
+			name:     "nested function arguments",
+			input:    "SELECT DATE_TRUNC('day', NOW() - INTERVAL '1 day', 'UTC') FROM t",
+			expected: "SELECT DATE_TRUNC('day', NOW() - INTERVAL ?, 'UTC') FROM t",
+			opts:     []obfuscatorOption{WithKeepLiteralsInFunctions("DATE_TRUNC")},
+		},
+		{
+			name:     "compared columns",
+			input:    "SELECT * FROM users u WHERE u.status = 'active' AND \"type\" <> 'admin' AND status NOT IN ('new', 'closed') AND email = 'a@b.c' AND kind LIKE 'x%'",
+			expected: "SELECT * FROM users u WHERE u.status = 'active' AND \"type\" <> 'admin' AND status NOT IN ('new', 'closed') AND email = ? AND kind LIKE 'x%'",
+			opts:     []obfuscatorOption{WithKeepLiteralsOfColumns("status", "type", "kind")},
+		},
+		{
+			name:     "columns inside an expression",
+			input:    "SELECT * FROM users WHERE LOWER(status) = 'active' AND status + 1 = 2 AND status IN (SELECT 'x')",
+			expected: "SELECT * FROM users WHERE LOWER(status) = ? AND status + ? = ? AND status IN (SELECT ?)",
+			opts:     []obfuscatorOption{WithKeepLiteralsOfColumns("status")},
+		},
+		{
+			name:     "keywords",
+			input:    "SELECT * FROM events WHERE at > NOW() - INTERVAL '1 day' AND id = 5 LIMIT 100 OFFSET 20",
+			expected: "SELECT * FROM events WHERE at > NOW() - INTERVAL '1 day' AND id = ? LIMIT 100 OFFSET ?",
+			opts:     []obfuscatorOption{WithKeepLiteralsAfterKeywords("interval", "LIMIT")},
+		},
+		{
+			name:     "regular expression",
+			input:    "SELECT * FROM prices WHERE currency = 'EUR' AND amount > 10 AND note = 'secret'",
+			expected: "SELECT * FROM prices WHERE currency = 'EUR' AND amount > ? AND note = ?",
+			opts:     []obfuscatorOption{WithKeepLiteralsMatching(regexp.MustCompile(`^'[A-Z]{3}'$`))},
+		},
+		{
+			name:     "kept literals are not numbered",
+			input:    "SELECT * FROM users WHERE status = 'active' AND id = 5 LIMIT 10",
+			expected: "SELECT * FROM users WHERE status = 'active' AND id = $1 LIMIT 10",
+			opts: []obfuscatorOption{
+				WithPlaceholder("$"),
+				WithNumberedPlaceholders(true),
+				WithKeepLiteralsOfColumns("status"),
+				WithKeepLiteralsAfterKeywords("LIMIT"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obfuscator := NewObfuscator(tt.opts...)
+			assert.Equal(t, tt.expected, obfuscator.Obfuscate(tt.input, WithDBMS(DBMSPostgres)))
+		})
+	}
+}
+
+func TestObfuscateTokenValueKeepLiterals(t *testing.T) {
+	obfuscator := NewObfuscator(
+		WithKeepLiteralsAfterKeywords("LIMIT"),
+		WithKeepLiteralsOfColumns("status"),
+		WithKeepLiteralsMatching(regexp.MustCompile(`^'[A-Z]{3}'$`)),
+	)
+	limit := Token{Type: IDENT, Value: "LIMIT"}
+	equal := Token{Type: OPERATOR, Value: "="}
+	assert.Equal(t, "100", obfuscator.ObfuscateTokenValue(Token{Type: NUMBER, Value: "100"}, limit))
+	assert.Equal(t, "'EUR'", obfuscator.ObfuscateTokenValue(Token{Type: STRING, Value: "'EUR'"}, equal))
+	// the column is not known from the last token alone
+	assert.Equal(t, "?", obfuscator.ObfuscateTokenValue(Token{Type: STRING, Value: "'active'"}, equal))
 }
 
 func ExampleObfuscator() {
@@ -0,0 +1,7 @@
+//go:build race
+
+package sqllexer
+
+// raceEnabled reports whether the tests run with the race detector,
+// which makes sync.Pool drop the items put back in it at random.
+const raceEnabled = true
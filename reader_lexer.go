@@ -0,0 +1,195 @@
+package sqllexer
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// readerLexerChunkSize is the number of bytes ReaderLexer reads at once.
+const readerLexerChunkSize = 4096
+
+// readerLexerMargin is the number of bytes ReaderLexer keeps ahead of the cursor before scanning a token,
+// on top of the longest line comment prefix, so that the prefixes of the tokens are never cut.
+const readerLexerMargin = 8
+
+// readerLexerDetectionSize is the number of bytes ReaderLexer guesses the DBMS from with WithDetectDBMS.
+const readerLexerDetectionSize = 4096
+
+// maxEmptyReads is the number of reads returning no data and no error after which ReaderLexer gives up,
+// like bufio.Reader does.
+const maxEmptyReads = 100
+
+// ReaderLexer scans the tokens of an input read from an io.Reader, e.g. a large migration script or pg_dump output.
+// It returns the same tokens as Lexer.Scan on the whole input, including their offsets, while keeping
+// only the current token and the few bytes after it in memory, so tokens can cross the reads in the middle
+// of a string or a comment. A token is returned once it is complete, i.e. once the input that follows it is read.
+//
+// The memory is bounded by the longest token rather than the input, except for the data of an
+// INSERT ... FORMAT statement, which runs until the end of the input.
+type ReaderLexer struct {
+	lexer     *Lexer
+	reader    io.Reader
+	chunkSize int    // the number of bytes to read at once
+	margin    int    // the number of bytes to keep ahead of the cursor
+	buffer    []byte // the buffer the unscanned input and the read bytes are gathered in
+	eof       bool   // whether the reader is exhausted
+	err       error  // the error returned by the reader, other than io.EOF
+	filled    bool   // whether the input was read yet
+}
+
+// NewReaderLexer returns a lexer scanning the input read from r, with the same options as New.
+// With WithDetectDBMS, the DBMS is guessed from the first 4KB of the input.
+func NewReaderLexer(r io.Reader, opts ...lexerOption) *ReaderLexer {
+	return &ReaderLexer{lexer: New("", opts...), reader: r, chunkSize: readerLexerChunkSize}
+}
+
+// Scan scans the next token and returns it, reading more of the input when the token needs it.
+// It returns an EOF token once the input is exhausted, or the reader failed, see Err.
+func (r *ReaderLexer) Scan() Token {
+	if !r.filled {
+		r.init()
+	}
+	if len(r.lexer.src)-r.lexer.cursor < r.margin {
+		r.fill(maxInt(r.margin, r.chunkSize))
+	}
+	for {
+		saved := *r.lexer
+		token := r.lexer.Scan()
+		if !r.eof && !r.complete(token, saved.cursor) {
+			// the token might go on in the input that is not read yet, scan it again with more input
+			*r.lexer = saved
+			r.fill(maxInt(len(r.lexer.src), r.chunkSize))
+			continue
+		}
+		// drop the input scanned before the token, whose positions are no longer needed
+		r.lexer.discard(saved.cursor)
+		return token
+	}
+}
+
+// complete reports whether the token, scanned from the start index of the unscanned input, is the same
+// whatever input follows, i.e. whether the lexer did not need to look past the end of what is read so far.
+// The lexer looks at most a few bytes past the token, except for the tags of dollar quoted strings,
+// the braced parameters and the client commands, which it looks for until the end of the line at most.
+func (r *ReaderLexer) complete(token Token, start int) bool {
+	src := r.lexer.src
+	if len(src)-r.lexer.cursor < r.margin {
+		return false
+	}
+	dialect := r.lexer.dialect
+	switch {
+	case token.Type == CLIENT_COMMAND:
+		// e.g. teradata BTEQ .LOGON tdpid/user,password
+		return strings.IndexByte(src[start:], '\n') >= 0
+	case src[start] == '$' && dialect.DollarQuotedStrings:
+		// the tag runs until the first character that is not alphanumeric, e.g. $tag$
+		return strings.IndexFunc(src[start+1:], func(ch rune) bool { return !isAlphaNumeric(ch) }) >= 0
+	case src[start] == '{' && dialect.BracedParameters:
+		// e.g. clickhouse {id:UInt32}
+		return strings.IndexAny(src[start+1:], "{}\n") >= 0
+	}
+	return true
+}
+
+// ScanAll scans the entire input and returns a slice of tokens.
+func (r *ReaderLexer) ScanAll() []Token {
+	var tokens []Token
+	for {
+		token := r.Scan()
+		if token.Type == EOF {
+			// don't include EOF token in the result
+			break
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// Keyword returns the keyword of the token, the same way as Lexer.Keyword.
+func (r *ReaderLexer) Keyword(token Token) string {
+	return r.lexer.Keyword(token)
+}
+
+// Unquote returns the content of a string token, the same way as Lexer.Unquote.
+func (r *ReaderLexer) Unquote(token Token) (string, bool) {
+	return r.lexer.Unquote(token)
+}
+
+// Position returns the position of the byte offset in the input, the same way as Lexer.Position.
+// Only the offsets of the last token returned by Scan and of the input read after it can be resolved,
+// the input before it is no longer held. ok is false for the other offsets, whose position is unknown.
+func (r *ReaderLexer) Position(offset int) (position Position, ok bool) {
+	position, ok = r.lexer.positionFrom(r.lexer.resolved, offset)
+	if !ok {
+		return Position{}, false
+	}
+	r.lexer.resolved = position
+	return position, true
+}
+
+// Errors returns the errors encountered so far, in the order they were found.
+// The tokens that caused them are still returned by Scan, usually as ERROR tokens.
+func (r *ReaderLexer) Errors() []*LexError {
+	return r.lexer.Errors()
+}
+
+// Err returns the error returned by the reader if any, or else the first error encountered so far.
+func (r *ReaderLexer) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.lexer.Err()
+}
+
+// init reads the beginning of the input, and guesses the DBMS from it if needed.
+func (r *ReaderLexer) init() {
+	r.filled = true
+	config := r.lexer.config
+	if config.DetectDBMS && config.DBMS == "" {
+		r.fill(readerLexerDetectionSize)
+		config.DBMS, _ = DetectDBMS(r.lexer.src)
+		r.lexer.dialect = dialectFor(config.DBMS)
+	}
+	r.margin = readerLexerMargin
+	for _, prefix := range r.lexer.dialect.LineCommentPrefixes {
+		r.margin = maxInt(r.margin, readerLexerMargin+len(prefix))
+	}
+	for _, placeholder := range config.placeholders {
+		r.margin = maxInt(r.margin, readerLexerMargin+len(placeholder))
+	}
+}
+
+// fill reads at least n more bytes of the input, or until the reader is exhausted,
+// and appends them to the unscanned input.
+func (r *ReaderLexer) fill(n int) {
+	if r.eof {
+		return
+	}
+	r.lexer.discard(r.lexer.cursor)
+	r.buffer = append(r.buffer[:0], r.lexer.src...)
+	target := len(r.buffer) + n
+	for emptyReads := 0; len(r.buffer) < target; {
+		if len(r.buffer) == cap(r.buffer) {
+			r.buffer = append(r.buffer, 0)[:len(r.buffer)]
+		}
+		read, err := r.reader.Read(r.buffer[len(r.buffer):cap(r.buffer)])
+		r.buffer = r.buffer[:len(r.buffer)+read]
+		if read == 0 && err == nil {
+			emptyReads++
+			if emptyReads < maxEmptyReads {
+				continue
+			}
+			err = io.ErrNoProgress
+		}
+		emptyReads = 0
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				r.err = err
+			}
+			r.eof = true
+			break
+		}
+	}
+	r.lexer.src = string(r.buffer)
+}
@@ -0,0 +1,190 @@
+package sqllexer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderLexer(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		lexerOpts []lexerOption
+	}{
+		{
+			name:  "simple select",
+			input: "SELECT * FROM users WHERE id = 1",
+		},
+		{
+			name:  "strings and comments across lines",
+			input: "SELECT 'it''s', \"quoted\".\"name\" -- trailing comment\nFROM users /* multi\nline */ WHERE name = 'a;b'",
+		},
+		{
+			name:  "unterminated string",
+			input: "SELECT * FROM users WHERE name = 'abc",
+		},
+		{
+			name:  "unterminated comment",
+			input: "SELECT 1 /* truncated",
+		},
+		{
+			name:  "multibyte runes",
+			input: "SELECT 'héllo wörld', 名前 FROM テーブル WHERE emoji = '😀'",
+		},
+		{
+			name:  "invalid utf8",
+			input: "SELECT '\xff' FROM t\xfe",
+		},
+		{
+			name:      "postgres dollar quoted function",
+			input:     "CREATE FUNCTION f() RETURNS int AS $func$ SELECT $1 + 'a$b' $func$ LANGUAGE sql; SELECT $$x$$",
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:      "sqlserver brackets and variables",
+			input:     "SELECT [dbo].[users].[id], @@VERSION FROM #temp WHERE id = @id",
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			name:      "mysql backticks and hash comments",
+			input:     "SELECT `id` FROM `users` # comment\nWHERE name = 'a\\'b'",
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
+		},
+		{
+			name:      "clickhouse braced parameters and format data",
+			input:     "SELECT arrayMap(x -> x + 1, {ids:Array(UInt32)}) FROM t;\nINSERT INTO t FORMAT JSONEachRow {\"id\": 1}\n{\"id\": 2}",
+			lexerOpts: []lexerOption{WithDBMS(DBMSClickHouse)},
+		},
+		{
+			name:      "teradata bteq commands",
+			input:     ".LOGON host/user,password\n  .SET WIDTH 200  \nSEL * FROM t;\n.QUIT",
+			lexerOpts: []lexerOption{WithDBMS(DBMSTeradata)},
+		},
+		{
+			name:      "detected dbms",
+			input:     "SELECT TOP 10 * FROM [dbo].[users]",
+			lexerOpts: []lexerOption{WithDetectDBMS(true)},
+		},
+		{
+			name:      "postgres long dollar quote tags",
+			input:     "SELECT $a_long_dollar_quote_tag$x$a_long_dollar_quote_tag$, $not_a_long_dollar_quote_tag FROM t",
+			lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)},
+		},
+		{
+			name:      "clickhouse long braced parameter",
+			input:     "SELECT {a_long_parameter_name:Array(Tuple(UInt32, String))}, {not_a_parameter: 1",
+			lexerOpts: []lexerOption{WithDBMS(DBMSClickHouse)},
+		},
+		{
+			name:      "teradata client command with spaces",
+			input:     ".LOGON host/user,password                    with spaces\n.LOGOFF                    ",
+			lexerOpts: []lexerOption{WithDBMS(DBMSTeradata)},
+		},
+		{
+			name:  "long string",
+			input: "SELECT '" + strings.Repeat("abc\n", 5000) + "', 1 FROM t",
+		},
+	}
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"whole":    func(r io.Reader) io.Reader { return r },
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+		"data err": iotest.DataErrReader,
+		"timeouts": func(r io.Reader) io.Reader { return iotest.OneByteReader(&emptyReader{r: r}) },
+	}
+
+	for _, tt := range tests {
+		expected := New(tt.input, tt.lexerOpts...)
+		expectedTokens := expected.ScanAll()
+		for name, reader := range readers {
+			// small chunks make the tokens cross the reads at every position
+			for _, chunkSize := range []int{1, 2, 3, 7, readerLexerChunkSize} {
+				t.Run(fmt.Sprintf("%s/%s/%d", tt.name, name, chunkSize), func(t *testing.T) {
+					lexer := NewReaderLexer(reader(strings.NewReader(tt.input)), tt.lexerOpts...)
+					lexer.chunkSize = chunkSize
+					var tokens []Token
+					for token := lexer.Scan(); token.Type != EOF; token = lexer.Scan() {
+						tokens = append(tokens, token)
+						// the positions of the last token are still known
+						end := token.Offset + len(token.Value)
+						start, ok := lexer.Position(token.Offset)
+						assert.True(t, ok)
+						assert.Equal(t, expected.Position(token.Offset), start)
+						stop, ok := lexer.Position(end)
+						assert.True(t, ok)
+						assert.Equal(t, expected.Position(end), stop)
+					}
+					assert.Equal(t, expectedTokens, tokens)
+					assert.Equal(t, expected.Errors(), lexer.Errors())
+					assert.Equal(t, EOF, lexer.Scan().Type)
+				})
+			}
+		}
+	}
+}
+
+func TestReaderLexerReadError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	reader := io.MultiReader(strings.NewReader("SELECT 'abc"), iotest.ErrReader(readErr))
+	lexer := NewReaderLexer(reader)
+	tokens := withoutPositions(lexer.ScanAll())
+	assert.Equal(t, []Token{
+		{Type: IDENT, Value: "SELECT"},
+		{Type: WS, Value: " "},
+		{Type: INCOMPLETE_STRING, Value: "'abc"},
+	}, tokens)
+	assert.ErrorIs(t, lexer.Err(), readErr)
+}
+
+func TestReaderLexerDiscardedPosition(t *testing.T) {
+	input := "SELECT *\nFROM users\nWHERE id = 1"
+	lexer := NewReaderLexer(strings.NewReader(input))
+	lexer.chunkSize = 1
+	token := lexer.Scan()
+	for token.Value != "id" {
+		token = lexer.Scan()
+	}
+	position, ok := lexer.Position(token.Offset)
+	assert.True(t, ok)
+	assert.Equal(t, Position{Offset: 26, Line: 3, Column: 7}, position)
+	// the beginning of the input is no longer held, and the end of the input is past it
+	_, ok = lexer.Position(0)
+	assert.False(t, ok)
+	_, ok = lexer.Position(len(input) + 1)
+	assert.False(t, ok)
+}
+
+// emptyReader returns no data and no error every other read.
+type emptyReader struct {
+	r     io.Reader
+	empty bool
+}
+
+func (e *emptyReader) Read(p []byte) (int, error) {
+	e.empty = !e.empty
+	if e.empty {
+		return 0, nil
+	}
+	return e.r.Read(p)
+}
+
+func ExampleReaderLexer() {
+	lexer := NewReaderLexer(strings.NewReader("SELECT * FROM users WHERE name = 'a;b'"))
+	for {
+		token := lexer.Scan()
+		if token.Type == EOF {
+			break
+		}
+		if token.Type != WS {
+			fmt.Printf("%q ", token.Value)
+		}
+	}
+	// Output: "SELECT" "*" "FROM" "users" "WHERE" "name" "=" "'a;b'"
+}
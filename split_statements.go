@@ -0,0 +1,281 @@
+package sqllexer
+
+import "strings"
+
+// Statement is a single statement found in a multi-statement input.
+type Statement struct {
+	Text  string   // the statement text, without the terminator and the surrounding whitespace
+	Start Position // position of the first character of the statement
+	End   Position // position immediately after the last character of the statement
+}
+
+// SplitStatements splits the input into individual statements.
+// Statements are separated by semicolons, and by the GO (SQL Server) and / (Oracle) batch terminators
+// when they stand alone on their line. DB2 scripts can change the terminator with --#SET TERMINATOR,
+// and Teradata BTEQ commands, e.g. .LOGON, are separators of their own. Semicolons inside strings, comments, dollar quoted strings
+// and BEGIN ... END blocks do not split the input.
+// Each statement can then be obfuscated and normalized on its own.
+func SplitStatements(input string, lexerOpts ...lexerOption) []Statement {
+	lexer := New(
+		input,
+		lexerOpts...,
+	)
+	splitter := newStatementSplitter(lexer, lexer.ScanAll())
+
+	var statements []Statement
+	for i := range splitter.tokens {
+		if !splitter.next(i) {
+			continue
+		}
+		if statement, ok := splitter.end(); ok {
+			statements = append(statements, statement)
+		}
+	}
+	if statement, ok := splitter.end(); ok {
+		statements = append(statements, statement)
+	}
+
+	return statements
+}
+
+// statementSplitter tracks the block nesting of a token stream to find the statement terminators.
+type statementSplitter struct {
+	lexer   *Lexer // the lexer that scanned the tokens, for the positions of the statements
+	dialect *Dialect
+	tokens  []Token
+
+	first, last int  // the first and last tokens of the current statement that are not whitespace, or -1
+	hasCode     bool // whether the current statement has anything else than comments
+
+	depth        int    // nesting of BEGIN ... END and CASE ... END blocks
+	declarations int    // Oracle declaration sections (DECLARE, IS, AS) waiting for their BEGIN
+	routine      bool   // whether the next IS or AS opens the declaration section of an Oracle routine
+	skipUntil    int    // index of the last token swallowed by a batch terminator, e.g. the count of GO 5
+	lastKeyword  string // the last token that is not whitespace or comment, uppercased
+	started      bool   // whether the statement has a token that is not whitespace or comment yet
+	terminator   string // the statement terminator, ; unless changed by a DB2 --#SET TERMINATOR directive
+}
+
+func newStatementSplitter(lexer *Lexer, tokens []Token) *statementSplitter {
+	return &statementSplitter{
+		lexer:      lexer,
+		dialect:    &lexer.dialect.Dialect,
+		tokens:     tokens,
+		first:      -1,
+		last:       -1,
+		skipUntil:  -1,
+		terminator: ";",
+	}
+}
+
+// next moves on to the token at index i, and reports whether it ends the current statement, see end.
+// It must be called for every token in order.
+func (s *statementSplitter) next(i int) bool {
+	if s.isTerminator(i) {
+		return true
+	}
+	switch s.tokens[i].Type {
+	case WS:
+		return false
+	case COMMENT, MULTILINE_COMMENT:
+	default:
+		s.hasCode = true
+	}
+	if s.first < 0 {
+		s.first = i
+	}
+	s.last = i
+	return false
+}
+
+// end ends the current statement and returns it, or false if it has nothing else than whitespace and comments.
+func (s *statementSplitter) end() (Statement, bool) {
+	first, last, hasCode := s.first, s.last, s.hasCode
+	s.first, s.last, s.hasCode = -1, -1, false
+	if first < 0 || !hasCode {
+		return Statement{}, false
+	}
+	start, end := s.tokens[first].Offset, s.tokens[last].Offset+len(s.tokens[last].Value)
+	return Statement{
+		Text:  s.lexer.src[start:end],
+		Start: s.lexer.Position(start),
+		End:   s.lexer.Position(end),
+	}, true
+}
+
+// terminatorDirective is the prefix of the DB2 comments that change the statement terminator.
+const terminatorDirective = "--#SET TERMINATOR"
+
+// isTerminator reports whether the token at index i ends the current statement.
+// It must be called for every token in order, as it updates the block nesting.
+func (s *statementSplitter) isTerminator(i int) bool {
+	if i <= s.skipUntil {
+		return true
+	}
+	token := &s.tokens[i]
+	if token.Type == CLIENT_COMMAND {
+		s.reset()
+		return true
+	}
+	if token.Type == COMMENT && s.dialect.TerminatorDirectives {
+		s.terminator = terminatorOf(token.Value, s.terminator)
+	}
+	if token.Type == WS || token.Type == COMMENT || token.Type == MULTILINE_COMMENT {
+		return false
+	}
+	keyword := token.Value
+	if token.Type == IDENT {
+		keyword = word(token)
+	}
+
+	if (token.Type == PUNCTUATION || token.Type == OPERATOR) && token.Value == s.terminator {
+		s.routine = false
+		if s.depth == 0 {
+			s.reset()
+			return true
+		}
+	}
+	if s.dialect.BatchSeparator != "" && strings.EqualFold(token.Value, s.dialect.BatchSeparator) {
+		end := i
+		if next := s.nextSignificant(i); next > 0 && strings.EqualFold(s.dialect.BatchSeparator, "GO") && s.tokens[next].Type == NUMBER && s.isAloneOnLine(i, next) {
+			// GO can be followed by a count, e.g. GO 5, while / 2 is a division
+			end = next
+		}
+		if s.isAloneOnLine(i, end) {
+			s.reset()
+			s.skipUntil = end
+			return true
+		}
+	}
+	if token.Type == IDENT {
+		switch keyword {
+		case "BEGIN":
+			if s.declarations > 0 {
+				// the BEGIN closes the declaration section, which already counts as a block
+				s.declarations--
+			} else if s.isBlockBegin(i) {
+				s.depth++
+			}
+		case "CASE":
+			if s.lastKeyword != "END" {
+				s.depth++
+			}
+		case "END":
+			if s.depth > 0 && s.isBlockEnd(i) {
+				s.depth--
+			}
+		case "DECLARE":
+			if s.dialect.PLSQLBlocks {
+				s.depth++
+				s.declarations++
+			}
+		case "PROCEDURE", "FUNCTION", "PACKAGE":
+			if s.dialect.PLSQLBlocks {
+				s.routine = true
+			}
+		case "BODY":
+			if s.dialect.PLSQLBlocks && s.lastKeyword == "TYPE" {
+				s.routine = true
+			}
+		case "IS", "AS":
+			if s.routine {
+				s.routine = false
+				s.depth++
+				s.declarations++
+			}
+		}
+	}
+	s.lastKeyword = keyword
+	s.started = true
+	return false
+}
+
+// terminatorOf returns the statement terminator set by the comment if it is a terminator directive,
+// e.g. @ for --#SET TERMINATOR @, and the current terminator otherwise.
+func terminatorOf(comment string, current string) string {
+	if len(comment) < len(terminatorDirective) || !strings.EqualFold(comment[:len(terminatorDirective)], terminatorDirective) {
+		return current
+	}
+	if terminator := strings.TrimSpace(comment[len(terminatorDirective):]); terminator != "" {
+		return terminator
+	}
+	return current
+}
+
+// reset clears the block nesting at the end of a statement.
+func (s *statementSplitter) reset() {
+	s.depth = 0
+	s.declarations = 0
+	s.routine = false
+	s.lastKeyword = ""
+	s.started = false
+}
+
+// isBlockBegin reports whether the BEGIN at index i opens a block rather than a transaction.
+// A transaction starts its statement, so a BEGIN after anything else opens a block, e.g. AS BEGIN or DO BEGIN.
+// A BEGIN starting the statement opens one only in the dialects with PL/SQL or SQL Server blocks.
+func (s *statementSplitter) isBlockBegin(i int) bool {
+	next := s.nextSignificant(i)
+	if next < 0 {
+		return false
+	}
+	if s.tokens[next].Value == s.terminator {
+		return false
+	}
+	switch word(&s.tokens[next]) {
+	case "TRANSACTION", "TRAN", "WORK", "DISTRIBUTED", "ISOLATION", "READ", "DEFERRED", "IMMEDIATE", "EXCLUSIVE":
+		return false
+	}
+	return s.started || s.dialect.PLSQLBlocks || s.dialect.BeginBlocks
+}
+
+// isBlockEnd reports whether the END at index i closes a block.
+// END IF, END LOOP and friends close control flow statements that are not counted as blocks.
+func (s *statementSplitter) isBlockEnd(i int) bool {
+	next := s.nextSignificant(i)
+	if next < 0 {
+		return true
+	}
+	switch word(&s.tokens[next]) {
+	case "IF", "LOOP", "WHILE", "REPEAT", "FOR":
+		return false
+	}
+	return true
+}
+
+// nextSignificant returns the index of the next token after i that is not whitespace or a comment, or -1.
+func (s *statementSplitter) nextSignificant(i int) int {
+	for j := i + 1; j < len(s.tokens); j++ {
+		switch s.tokens[j].Type {
+		case WS, COMMENT, MULTILINE_COMMENT:
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// isAloneOnLine reports whether the tokens from index first to last are the only tokens on their line.
+func (s *statementSplitter) isAloneOnLine(first, last int) bool {
+	if first > 0 {
+		previous := &s.tokens[first-1]
+		if previous.Type != WS || !strings.Contains(previous.Value, "\n") {
+			return false
+		}
+	}
+	for j := first + 1; j < last; j++ {
+		if s.tokens[j].Type != WS || strings.Contains(s.tokens[j].Value, "\n") {
+			return false
+		}
+	}
+	if last+1 < len(s.tokens) {
+		next := &s.tokens[last+1]
+		if next.Type != WS {
+			return false
+		}
+		if !strings.Contains(next.Value, "\n") && last+2 < len(s.tokens) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,251 @@
+package sqllexer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  []string
+		lexerOpts []lexerOption
+	}{
+		{
+			name:     "single statement",
+			input:    "SELECT * FROM users",
+			expected: []string{"SELECT * FROM users"},
+		},
+		{
+			name:     "transaction",
+			input:    "BEGIN; UPDATE users SET name = 'a;b' WHERE id = 1; INSERT INTO logs VALUES (1); COMMIT;",
+			expected: []string{"BEGIN", "UPDATE users SET name = 'a;b' WHERE id = 1", "INSERT INTO logs VALUES (1)", "COMMIT"},
+		},
+		{
+			name:     "transaction on several lines",
+			input:    "BEGIN\nUPDATE users SET name = 'a' WHERE id = 1;\nCOMMIT;",
+			expected: []string{"BEGIN\nUPDATE users SET name = 'a' WHERE id = 1", "COMMIT"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSPostgres),
+			},
+		},
+		{
+			name:     "mysql transaction and procedure",
+			input:    "BEGIN;\nUPDATE users SET active = 1;\nCOMMIT;\nCREATE PROCEDURE p() BEGIN UPDATE users SET active = 0; DELETE FROM logs; END;",
+			expected: []string{"BEGIN", "UPDATE users SET active = 1", "COMMIT", "CREATE PROCEDURE p() BEGIN UPDATE users SET active = 0; DELETE FROM logs; END"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSMySQL),
+			},
+		},
+		{
+			name:     "do block",
+			input:    "DO BEGIN UPDATE users SET active = 1; END; SELECT 1",
+			expected: []string{"DO BEGIN UPDATE users SET active = 1; END", "SELECT 1"},
+		},
+		{
+			name:     "begin transaction",
+			input:    "BEGIN TRANSACTION; DELETE FROM users; COMMIT",
+			expected: []string{"BEGIN TRANSACTION", "DELETE FROM users", "COMMIT"},
+		},
+		{
+			name:     "empty statements and whitespace",
+			input:    "  ;; SELECT 1 ;\n\n ; ",
+			expected: []string{"SELECT 1"},
+		},
+		{
+			name:     "comments",
+			input:    "/* first; */ SELECT 1; -- second; still a comment\nSELECT 2; -- trailing",
+			expected: []string{"/* first; */ SELECT 1", "-- second; still a comment\nSELECT 2"},
+		},
+		{
+			name:     "dollar quoted function",
+			input:    "CREATE FUNCTION f() RETURNS int AS $func$ BEGIN RETURN 1; END; $func$ LANGUAGE plpgsql; SELECT f()",
+			expected: []string{"CREATE FUNCTION f() RETURNS int AS $func$ BEGIN RETURN 1; END; $func$ LANGUAGE plpgsql", "SELECT f()"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSPostgres),
+			},
+		},
+		{
+			name:     "case expression",
+			input:    "SELECT CASE WHEN a = 1 THEN 'x' ELSE 'y' END FROM t; SELECT 2",
+			expected: []string{"SELECT CASE WHEN a = 1 THEN 'x' ELSE 'y' END FROM t", "SELECT 2"},
+		},
+		{
+			name: "sql server batches",
+			input: `CREATE PROCEDURE p AS
+BEGIN
+	UPDATE users SET active = 1;
+	IF @@ROWCOUNT = 0
+	BEGIN
+		INSERT INTO users DEFAULT VALUES;
+	END
+END
+GO
+SELECT * FROM users;
+go 5
+EXEC p`,
+			expected: []string{
+				"CREATE PROCEDURE p AS\nBEGIN\n\tUPDATE users SET active = 1;\n\tIF @@ROWCOUNT = 0\n\tBEGIN\n\t\tINSERT INTO users DEFAULT VALUES;\n\tEND\nEND",
+				"SELECT * FROM users",
+				"EXEC p",
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+			},
+		},
+		{
+			name: "sql server try catch",
+			input: `BEGIN TRY
+	DELETE FROM users;
+END TRY
+BEGIN CATCH
+	SELECT ERROR_MESSAGE();
+END CATCH;
+SELECT 1`,
+			expected: []string{
+				"BEGIN TRY\n\tDELETE FROM users;\nEND TRY\nBEGIN CATCH\n\tSELECT ERROR_MESSAGE();\nEND CATCH",
+				"SELECT 1",
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+			},
+		},
+		{
+			name:     "sql server block and transaction",
+			input:    "BEGIN\n UPDATE a SET x = 1; DELETE FROM b; END;\nBEGIN TRAN; UPDATE a SET x = 2; COMMIT",
+			expected: []string{"BEGIN\n UPDATE a SET x = 1; DELETE FROM b; END", "BEGIN TRAN", "UPDATE a SET x = 2", "COMMIT"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSSQLServer),
+			},
+		},
+		{
+			name:      "go is only a terminator in sql server",
+			input:     "SELECT 1\nGO\nSELECT 2",
+			expected:  []string{"SELECT 1\nGO\nSELECT 2"},
+			lexerOpts: []lexerOption{},
+		},
+		{
+			name: "oracle anonymous block",
+			input: `DECLARE
+	v NUMBER;
+BEGIN
+	SELECT COUNT(*) INTO v FROM users;
+	IF v > 0 THEN
+		UPDATE users SET active = 1;
+	END IF;
+END;
+/
+SELECT 1 FROM dual`,
+			expected: []string{
+				"DECLARE\n\tv NUMBER;\nBEGIN\n\tSELECT COUNT(*) INTO v FROM users;\n\tIF v > 0 THEN\n\t\tUPDATE users SET active = 1;\n\tEND IF;\nEND",
+				"SELECT 1 FROM dual",
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSOracle),
+			},
+		},
+		{
+			name:     "oracle division on its own line",
+			input:    "SELECT 10\n/ 2 FROM dual\n/\nSELECT 1 FROM dual",
+			expected: []string{"SELECT 10\n/ 2 FROM dual", "SELECT 1 FROM dual"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSOracle),
+			},
+		},
+		{
+			name: "oracle package body",
+			input: `CREATE OR REPLACE PACKAGE BODY pkg AS
+	PROCEDURE a IS
+		x NUMBER := 1 / 2;
+	BEGIN
+		CASE x WHEN 1 THEN NULL; ELSE NULL; END CASE;
+	END a;
+END pkg;
+/
+CREATE OR REPLACE PROCEDURE b AS BEGIN NULL; END;
+SELECT 1 FROM dual`,
+			expected: []string{
+				"CREATE OR REPLACE PACKAGE BODY pkg AS\n\tPROCEDURE a IS\n\t\tx NUMBER := 1 / 2;\n\tBEGIN\n\t\tCASE x WHEN 1 THEN NULL; ELSE NULL; END CASE;\n\tEND a;\nEND pkg",
+				"CREATE OR REPLACE PROCEDURE b AS BEGIN NULL; END",
+				"SELECT 1 FROM dual",
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSOracle),
+			},
+		},
+		{
+			name: "db2 terminator directive",
+			input: `--#SET TERMINATOR @
+CREATE PROCEDURE p() BEGIN UPDATE t SET a = 1; DELETE FROM u; END@
+CALL p()@
+--#SET TERMINATOR ;
+SELECT 1 FROM sysibm.sysdummy1;`,
+			expected: []string{
+				"--#SET TERMINATOR @\nCREATE PROCEDURE p() BEGIN UPDATE t SET a = 1; DELETE FROM u; END",
+				"CALL p()",
+				"--#SET TERMINATOR ;\nSELECT 1 FROM sysibm.sysdummy1",
+			},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSDB2),
+			},
+		},
+		{
+			name: "teradata bteq commands",
+			input: `.LOGON tdprod/etl_user,secret
+SEL * FROM sales.orders
+.EXPORT FILE = out.txt
+SEL 1;
+.QUIT`,
+			expected: []string{"SEL * FROM sales.orders", "SEL 1"},
+			lexerOpts: []lexerOption{
+				WithDBMS(DBMSTeradata),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := SplitStatements(tt.input, tt.lexerOpts...)
+			var got []string
+			for _, statement := range statements {
+				got = append(got, statement.Text)
+				// the positions must point back to the statement in the input
+				assert.Equal(t, statement.Text, tt.input[statement.Start.Offset:statement.End.Offset])
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSplitStatementsPositions(t *testing.T) {
+	statements := SplitStatements("SELECT 1;\n  DELETE FROM users;")
+	assert.Equal(t, []Statement{
+		{
+			Text:  "SELECT 1",
+			Start: Position{0, 1, 1},
+			End:   Position{8, 1, 9},
+		},
+		{
+			Text:  "DELETE FROM users",
+			Start: Position{12, 2, 3},
+			End:   Position{29, 2, 20},
+		},
+	}, statements)
+}
+
+func ExampleSplitStatements() {
+	normalizer := NewNormalizer(
+		WithCollectCommands(true),
+		WithCollectTables(true),
+	)
+
+	for _, statement := range SplitStatements("SELECT * FROM a; DELETE FROM b WHERE id = ?") {
+		normalizedSQL, statementMetadata, _ := normalizer.Normalize(statement.Text)
+		fmt.Println(normalizedSQL, statementMetadata.Commands, statementMetadata.Tables)
+	}
+	// Output: SELECT * FROM a [SELECT] [a]
+	// DELETE FROM b WHERE id = ? [DELETE] [b]
+}
@@ -1,8 +1,13 @@
 package sqllexer
 
-import "unicode/utf8"
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
 
-type TokenType int
+type TokenType int32
 
 const (
 	ERROR TokenType = iota
@@ -25,16 +30,34 @@ const (
 	FUNCTION               // function
 	SYSTEM_VARIABLE        // system variable
 	UNKNOWN                // unknown token
+	FORMAT_DATA            // the data of an INSERT ... FORMAT statement, e.g. clickhouse rows in JSONEachRow
+	CLIENT_COMMAND         // a command of the client rather than SQL, e.g. teradata BTEQ .LOGON
 )
 
-// Token represents a SQL token with its type and value.
+// Position represents a location in the input string.
+type Position struct {
+	Offset int `json:"offset"` // byte offset, starting at 0
+	Line   int `json:"line"`   // line number, starting at 1
+	Column int `json:"column"` // column number in runes, starting at 1
+}
+
+// Token represents a SQL token with its type, value and location in the input string.
+// Tokens do not carry their end offset nor their lines and columns, which would make them larger and the lexer slower:
+// the value ends at the byte offset Offset+len(Value), and the line and column of both ends are given by Lexer.Position.
 type Token struct {
-	Type  TokenType
-	Value string
+	Type      TokenType
+	KeywordID KeywordID // the keyword an IDENT or a FUNCTION stands for, e.g. SELECT for select, see Lexer.Keyword
+	Value     string
+	Offset    int // byte offset of the first character of the token, starting at 0
 }
 
 type LexerConfig struct {
 	DBMS DBMSType `json:"dbms,omitempty"`
+	// DetectDBMS specifies whether the lexer should guess the DBMS from the input with DetectDBMS
+	// when it is not set with WithDBMS.
+	DetectDBMS bool `json:"detect_dbms,omitempty"`
+
+	placeholders []string // the placeholders the literals of the input were replaced with, see WithPlaceholders
 }
 
 type lexerOption func(*LexerConfig)
@@ -45,39 +68,120 @@ func WithDBMS(dbms DBMSType) lexerOption {
 	}
 }
 
+func WithDetectDBMS(detectDBMS bool) lexerOption {
+	return func(c *LexerConfig) {
+		c.DetectDBMS = detectDBMS
+	}
+}
+
 // SQL Lexer inspired from Rob Pike's talk on Lexical Scanning in Go
 type Lexer struct {
-	src    string // the input src string
-	cursor int    // the current position of the cursor
-	start  int    // the start position of the current token
-	config *LexerConfig
+	src      string   // the input src string
+	origin   Position // the position of src in the whole input, not its start when the input is read in chunks
+	resolved Position // the last position returned by Position, the next one is counted from
+	scanned  Position // the last position the scanner resolved, e.g. the end of the last error, apart from resolved
+	cursor   int      // the current position of the cursor
+	start    int      // the start position of the current token
+	config   *LexerConfig
+
+	dialect    *dialectEntry // the lexical rules and the words of the DBMS
+	detectDBMS bool          // whether the DBMS is guessed from each input, see WithDetectDBMS
+
+	lastKeyword     string // the keyword of the last token that is not whitespace or comment
+	typeDepth       int    // the nesting of the angle brackets of ARRAY<...> and STRUCT<...> types
+	insertStatement bool   // whether the current statement is an INSERT, whose FORMAT clause can be followed by data
+	formatData      bool   // whether the rest of the input is the data of an INSERT ... FORMAT statement
+	lineStart       bool   // whether the cursor is preceded by nothing but whitespace on its line
+	nonASCII        bool   // whether a byte that is not ASCII was consumed since the start of the current token
+
+	errKind ErrorKind   // the kind of error encountered while scanning the current token
+	errors  []*LexError // the errors encountered so far
 }
 
 func New(input string, opts ...lexerOption) *Lexer {
-	lexer := &Lexer{src: input, config: &LexerConfig{}}
+	lexer := &Lexer{config: &LexerConfig{}}
+	lexer.configure(opts)
+	lexer.Reset(input)
+	return lexer
+}
+
+// configure replaces the configuration of the lexer with the one of the options.
+func (s *Lexer) configure(opts []lexerOption) {
+	*s.config = LexerConfig{}
 	for _, opt := range opts {
-		opt(lexer.config)
+		opt(s.config)
+	}
+	s.detectDBMS = s.config.DetectDBMS && s.config.DBMS == ""
+}
+
+// Reset makes the lexer scan a new input from the start, with the same configuration.
+// It lets a lexer be reused from one input to the next without allocating a new one.
+// With WithDetectDBMS, the DBMS is guessed again from the new input.
+// The tokens and errors returned for the previous input are left untouched.
+func (s *Lexer) Reset(input string) {
+	*s = Lexer{src: input, origin: Position{Line: 1, Column: 1}, lineStart: true, config: s.config, detectDBMS: s.detectDBMS}
+	s.resolved = s.origin
+	s.scanned = s.origin
+	s.nonASCII = len(input) > 0 && input[0] >= utf8.RuneSelf
+	if s.detectDBMS {
+		s.config.DBMS, _ = DetectDBMS(input)
 	}
+	s.dialect = dialectFor(s.config.DBMS)
+}
+
+// lexerPool holds the lexers the obfuscator and the normalizer reuse from one input to the next.
+var lexerPool = sync.Pool{
+	New: func() any {
+		return &Lexer{config: &LexerConfig{}}
+	},
+}
+
+// getLexer returns a lexer from the pool, configured and reset as New would.
+// It must be handed back with putLexer once its tokens are scanned.
+func getLexer(input string, opts ...lexerOption) *Lexer {
+	lexer := lexerPool.Get().(*Lexer)
+	lexer.configure(opts)
+	lexer.Reset(input)
 	return lexer
 }
 
+// putLexer hands the lexer back to the pool, without holding on to its input and errors.
+func putLexer(lexer *Lexer) {
+	*lexer = Lexer{config: lexer.config}
+	lexerPool.Put(lexer)
+}
+
 // ScanAll scans the entire input string and returns a slice of tokens.
 func (s *Lexer) ScanAll() []Token {
-	var tokens []Token
+	return s.ScanInto(nil)
+}
+
+// ScanInto scans the rest of the input and appends its tokens to dst, returning the extended slice.
+// Along with Reset, it lets the same slice be reused from one input to the next without allocating,
+// e.g. tokens = lexer.ScanInto(tokens[:0]).
+func (s *Lexer) ScanInto(dst []Token) []Token {
 	for {
 		token := s.Scan()
 		if token.Type == EOF {
 			// don't include EOF token in the result
 			break
 		}
-		tokens = append(tokens, token)
+		dst = append(dst, token)
 	}
-	return tokens
+	return dst
 }
 
 // ScanAllTokens scans the entire input string and returns a channel of tokens.
 // Use this if you want to process the tokens as they are scanned.
+// The channel must be read until it is closed, or the goroutine scanning the input is never released;
+// use ScanAllTokensContext to stop reading early.
 func (s *Lexer) ScanAllTokens() <-chan Token {
+	return s.ScanAllTokensContext(context.Background())
+}
+
+// ScanAllTokensContext is like ScanAllTokens, but it stops scanning and closes the channel
+// as soon as the context is done, so that the consumer can stop reading early by cancelling it.
+func (s *Lexer) ScanAllTokensContext(ctx context.Context) <-chan Token {
 	tokenCh := make(chan Token)
 
 	go func() {
@@ -89,7 +193,11 @@ func (s *Lexer) ScanAllTokens() <-chan Token {
 				// don't include EOF token in the result
 				break
 			}
-			tokenCh <- token
+			select {
+			case tokenCh <- token:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
@@ -97,22 +205,187 @@ func (s *Lexer) ScanAllTokens() <-chan Token {
 }
 
 // Scan scans the next token and returns it.
+// The returned token carries the byte offset of its value in the input string.
 func (s *Lexer) Scan() Token {
+	offset := s.origin.Offset + s.cursor
+	token := s.scan()
+	token.Offset = offset
+	if token.Type == IDENT || token.Type == FUNCTION {
+		token.KeywordID = s.dialect.dictionary.lookupKeywordID(token.Value)
+	}
+	if (s.dialect.InsertFormatData || s.dialect.AngleBracketTypes) && token.Type != WS && token.Type != COMMENT && token.Type != MULTILINE_COMMENT {
+		// only the dialects whose tokens depend on the keywords before them spend time looking them up
+		keyword := word(&token)
+		if s.dialect.InsertFormatData {
+			s.trackInsertFormat(&token, keyword)
+		}
+		s.lastKeyword = keyword
+	}
+	if s.dialect.DotCommands {
+		s.lineStart = token.Type == WS && (s.lineStart || strings.Contains(token.Value, "\n"))
+	}
+	if s.nonASCII {
+		// only the tokens holding bytes that are not ASCII are checked, e.g. a string holding invalid bytes,
+		// which are kept in its value
+		if s.errKind == noError && !utf8.ValidString(token.Value) {
+			s.errKind = InvalidUTF8
+		}
+		// the byte at the cursor, consumed along with the token, starts the next one
+		s.nonASCII = s.cursor < len(s.src) && s.src[s.cursor] >= utf8.RuneSelf
+	}
+	if s.errKind != noError {
+		// the positions are resolved from the scanner's own position, so that Position can be called
+		// while the input is scanned by another goroutine, see ScanAllTokens
+		start, _ := s.positionFrom(s.scanned, token.Offset)
+		s.scanned, _ = s.positionFrom(start, token.Offset+len(token.Value))
+		s.errors = append(s.errors, &LexError{
+			Kind:    s.errKind,
+			Start:   start,
+			End:     s.scanned,
+			Partial: token.Value,
+		})
+		s.errKind = noError
+	}
+	return token
+}
+
+// trackInsertFormat keeps track of the INSERT statements, so that the data following their FORMAT clause
+// is scanned as a single token, e.g. clickhouse INSERT INTO t FORMAT JSONEachRow {"id": 1}.
+func (s *Lexer) trackInsertFormat(token *Token, keyword string) {
+	switch {
+	case keyword == "INSERT":
+		s.insertStatement = true
+	case token.Type == PUNCTUATION && token.Value == ";":
+		s.insertStatement = false
+	case s.insertStatement && s.lastKeyword == "FORMAT" && (token.Type == IDENT || token.Type == QUOTED_IDENT):
+		// the token is the name of the format, the data comes next
+		s.formatData = true
+	}
+}
+
+// Keyword returns the keyword of the token, i.e. the uppercase form of the value of an IDENT or a FUNCTION
+// that is a SQL keyword of the dialect, e.g. SELECT for select, and an empty string otherwise.
+// For an abbreviation, it is the word the abbreviation stands for, e.g. SELECT for teradata SEL.
+// The values that read like words, e.g. NULL or CURRENT_DATE, are not keywords.
+// It is the keyword Scan identified the token with, see Token.KeywordID.
+func (s *Lexer) Keyword(token Token) string {
+	return token.KeywordID.String()
+}
+
+// Unquote returns the content of a STRING token without its prefix, its quotes and the escapes of its characters,
+// e.g. it's for MySQL 'it\'s', and the content of a dollar quoted string, e.g. abc for $tag$abc$tag$.
+// A doubled quote stands for a quote, the other escapes are the ones of the dialect of the lexer.
+// The prefixes are dropped without decoding the content, e.g. 0A for X'0A'.
+// It returns false for the other tokens, including the strings cut short, e.g. 'abc.
+func (s *Lexer) Unquote(token Token) (string, bool) {
+	switch token.Type {
+	case STRING:
+		return unquoteString(token.Value, &s.dialect.Dialect), true
+	case DOLLAR_QUOTED_STRING, DOLLAR_QUOTED_FUNCTION:
+		return unquoteDollarQuoted(token.Value), true
+	}
+	return "", false
+}
+
+// Errors returns the errors encountered so far, in the order they were found.
+// The tokens that caused them are still returned by Scan, usually as ERROR tokens.
+func (s *Lexer) Errors() []*LexError {
+	return s.errors
+}
+
+// Err returns the first error encountered so far, or nil if the input was scanned cleanly.
+func (s *Lexer) Err() error {
+	if len(s.errors) == 0 {
+		return nil
+	}
+	return s.errors[0]
+}
+
+// Position returns the position of the byte offset in the input string, e.g. of the start of a token
+// with Token.Offset, or of its end with Token.Offset+len(Token.Value).
+// The lines and columns are counted from the last position returned, so that resolving the positions
+// of the tokens in the order they are scanned takes a single pass over the input.
+// Scan does not resolve positions from the same place, so the positions of the tokens sent by ScanAllTokens
+// can be resolved while the rest of the input is scanned.
+func (s *Lexer) Position(offset int) Position {
+	s.resolved, _ = s.positionFrom(s.resolved, offset)
+	return s.resolved
+}
+
+// positionFrom returns the position of the byte offset, counted from the given position unless it is past the offset.
+// ok is false if the offset is outside the input that is held, e.g. before the input ReaderLexer discarded,
+// in which case the position is the one of the nearest offset that is held.
+func (s *Lexer) positionFrom(from Position, offset int) (position Position, ok bool) {
+	if offset < from.Offset || from.Offset < s.origin.Offset {
+		from = s.origin
+	}
+	end := s.origin.Offset + len(s.src)
+	ok = offset >= s.origin.Offset && offset <= end
+	offset = minInt(maxInt(offset, from.Offset), end)
+	return from.advance(s.src[from.Offset-s.origin.Offset : offset-s.origin.Offset]), ok
+}
+
+// discard drops the first n bytes of the input, e.g. the ones ReaderLexer is done with,
+// while keeping the positions of the rest of the input.
+func (s *Lexer) discard(n int) {
+	s.origin, _ = s.positionFrom(s.scanned, s.origin.Offset+n)
+	s.src = s.src[n:]
+	s.cursor -= n
+}
+
+// from returns the position in an input that contains the scanned one at the origin position,
+// e.g. the position in a query of a token of the body of one of its functions.
+func (p Position) from(origin Position) Position {
+	if p.Line == 1 {
+		p.Column += origin.Column - 1
+	}
+	p.Line += origin.Line - 1
+	p.Offset += origin.Offset
+	return p
+}
+
+// advance returns the position immediately after the text, when the text starts at the position.
+func (p Position) advance(text string) Position {
+	p.Offset += len(text)
+	lastNewline := strings.LastIndexByte(text, '\n')
+	if lastNewline < 0 {
+		p.Column += utf8.RuneCountInString(text)
+		return p
+	}
+	p.Line += strings.Count(text, "\n")
+	p.Column = 1 + utf8.RuneCountInString(text[lastNewline+1:])
+	return p
+}
+
+func (s *Lexer) scan() Token {
 	ch := s.peek()
 	switch {
+	case s.formatData && !isWhitespace(ch) && !isEOF(ch):
+		return s.scanFormatData()
 	case isWhitespace(ch):
 		return s.scanWhitespace()
+	case len(s.config.placeholders) > 0 && s.placeholderLength() > 0:
+		// e.g. ?str, written by an obfuscator configured with WithStringPlaceholder
+		return s.scanPlaceholder()
 	case isLetter(ch):
+		if prefixLength := s.dialect.stringPrefix(s.src[s.cursor:]); prefixLength > 0 {
+			// e.g. bigquery r'raw string'
+			return s.scanString(prefixLength)
+		}
 		return s.scanIdentifier(ch)
-	case isDoubleQuote(ch):
-		return s.scanDoubleQuotedIdentifier('"')
-	case isSingleQuote(ch):
-		return s.scanString()
-	case isSingleLineComment(ch, s.lookAhead(1)):
-		return s.scanSingleLineComment()
+	case ch == '.' && s.dialect.DotCommands && isLetter(s.lookAhead(1)) && s.lineStart:
+		// e.g. teradata BTEQ .LOGON tdpid/user,password
+		return s.scanClientCommand()
+	case s.dialect.isIdentifierQuote(ch):
+		return s.scanDoubleQuotedIdentifier(ch)
+	case s.dialect.isStringQuote(ch):
+		return s.scanString(0)
 	case isMultiLineComment(ch, s.lookAhead(1)):
 		return s.scanMultiLineComment()
-	case isLeadingSign(ch):
+	case ch == '-' && s.lookAhead(1) == '>' && s.dialect.LambdaArrows:
+		// e.g. clickhouse arrayMap(x -> x + 1, arr)
+		return s.scanLambdaArrow()
+	case isLeadingSign(ch) && s.dialect.lineCommentPrefix(s.src[s.cursor:]) == 0:
 		// if the leading sign is followed by a digit, then it's a number
 		// although this is not strictly true, it's good enough for our purposes
 		nextCh := s.lookAhead(1)
@@ -124,59 +397,60 @@ func (s *Lexer) Scan() Token {
 		return s.scanNumber(ch)
 	case isWildcard(ch):
 		return s.scanWildcard()
-	case ch == '$':
-		if isDigit(s.lookAhead(1)) {
-			// if the dollar sign is followed by a digit, then it's a numbered parameter
-			return s.scanPositionalParameter()
-		}
-		if s.config.DBMS == DBMSSQLServer && isLetter(s.lookAhead(1)) {
-			return s.scanIdentifier(ch)
-		}
+	case ch == '$' && isDigit(s.lookAhead(1)):
+		// if the dollar sign is followed by a digit, then it's a numbered parameter
+		return s.scanPositionalParameter()
+	case ch == '?' && isDigit(s.lookAhead(1)) && s.dialect.NumberedQuestionMarks:
+		// e.g. sqlite ?1
+		return s.scanPositionalParameter()
+	case ch == '$' && s.dialect.DollarQuotedStrings && s.dollarQuoteTagLength() > 0:
 		return s.scanDollarQuotedString()
-	case ch == ':':
-		if s.config.DBMS == DBMSOracle && isAlphaNumeric(s.lookAhead(1)) {
-			return s.scanBindParameter()
-		}
-		return s.scanOperator(ch)
-	case ch == '`':
-		if s.config.DBMS == DBMSMySQL {
-			return s.scanDoubleQuotedIdentifier('`')
-		}
-		fallthrough
-	case ch == '#':
-		if s.config.DBMS == DBMSSQLServer {
-			return s.scanIdentifier(ch)
-		} else if s.config.DBMS == DBMSMySQL {
-			// MySQL treats # as a comment
-			return s.scanSingleLineComment()
-		}
-		fallthrough
-	case ch == '@':
-		if isAlphaNumeric(s.lookAhead(1)) {
-			if s.config.DBMS == DBMSSnowflake {
-				return s.scanIdentifier(ch)
-			}
-			return s.scanBindParameter()
-		} else if s.lookAhead(1) == '@' {
-			return s.scanSystemVariable()
-		}
-		fallthrough
-	case isOperator(ch):
-		return s.scanOperator(ch)
-	case isPunctuation(ch):
-		if ch == '[' && s.config.DBMS == DBMSSQLServer {
-			return s.scanDoubleQuotedIdentifier('[')
-		}
+	case s.dialect.isIdentifierPrefix(ch) && (isLetter(s.lookAhead(1)) || s.lookAhead(1) == ch):
+		// e.g. sqlserver #temp and ##temp, or snowflake @stage
+		return s.scanIdentifier(ch)
+	case s.dialect.isBindParameterPrefix(ch) && isAlphaNumeric(s.lookAhead(1)):
+		return s.scanBindParameter()
+	case ch == '{' && s.dialect.BracedParameters && s.bracedParameterLength() > 0:
+		// e.g. clickhouse {id:UInt32}
+		return s.scanBracedParameter()
+	case ch == '@' && s.lookAhead(1) == '@':
+		return s.scanSystemVariable()
+	case ch == '<' && s.dialect.AngleBracketTypes && (s.lastKeyword == "ARRAY" || s.lastKeyword == "STRUCT"):
+		// e.g. bigquery ARRAY<INT64>
+		s.typeDepth++
+		return s.scanPunctuation()
+	case ch == '>' && s.typeDepth > 0:
+		// the closing brackets of nested types are scanned one at a time, e.g. ARRAY<STRUCT<id INT64>>
+		s.typeDepth--
 		return s.scanPunctuation()
-	case isEOF(ch):
-		return Token{EOF, ""}
 	default:
-		return s.scanUnknown()
+		if prefixLength := s.dialect.lineCommentPrefix(s.src[s.cursor:]); prefixLength > 0 {
+			return s.scanSingleLineComment(prefixLength)
+		}
+		switch {
+		case isOperator(ch):
+			return s.scanOperator(ch)
+		case isPunctuation(ch):
+			return s.scanPunctuation()
+		case isEOF(ch):
+			return Token{Type: EOF}
+		default:
+			return s.scanUnknown()
+		}
 	}
 }
 
 // lookAhead returns the rune n positions ahead of the cursor.
 func (s *Lexer) lookAhead(n int) rune {
+	// most of the input is ASCII, whose bytes are runes, the fast path is kept small enough to be inlined
+	if i := s.cursor + n; uint(i) < uint(len(s.src)) && s.src[i] < utf8.RuneSelf {
+		return rune(s.src[i])
+	}
+	return s.decodeAhead(n)
+}
+
+// decodeAhead returns the rune n positions ahead of the cursor, when it is not ASCII or out of the input.
+func (s *Lexer) decodeAhead(n int) rune {
 	if s.cursor+n >= len(s.src) || s.cursor+n < 0 {
 		return 0
 	}
@@ -191,14 +465,32 @@ func (s *Lexer) peek() rune {
 
 // nextBy advances the cursor by n positions and returns the rune at the cursor position.
 func (s *Lexer) nextBy(n int) rune {
-	// advance the cursor by n and return the rune at the cursor position
+	// the fast path of the ASCII input, consumed one byte at a time, is kept small enough to be inlined
+	if i := s.cursor + 1; n == 1 && uint(i) < uint(len(s.src)) && s.src[i] < utf8.RuneSelf {
+		s.cursor = i
+		return rune(s.src[i])
+	}
+	return s.advance(n)
+}
+
+// advance advances the cursor by n positions and returns the rune at the cursor position,
+// keeping track of the bytes that are not ASCII, whose tokens are checked for invalid UTF-8 by Scan.
+func (s *Lexer) advance(n int) rune {
 	if s.cursor+n > len(s.src) {
 		return 0
 	}
+	if n > 1 && !s.nonASCII {
+		// the bytes skipped over are consumed too, e.g. the ones of a braced parameter
+		s.nonASCII = !isASCII(s.src[s.cursor+1 : s.cursor+n])
+	}
 	s.cursor += n
 	if s.cursor >= len(s.src) {
 		return 0
 	}
+	if ch := s.src[s.cursor]; ch < utf8.RuneSelf {
+		return rune(ch)
+	}
+	s.nonASCII = true
 	r, _ := utf8.DecodeRuneInString(s.src[s.cursor:])
 	return r
 }
@@ -236,6 +528,8 @@ func (s *Lexer) scanNumberic(ch rune) Token {
 		nextCh := s.lookAhead(1)
 		if nextCh == 'x' || nextCh == 'X' {
 			return s.scanHexNumber()
+		} else if (nextCh == 'b' || nextCh == 'B') && isBinaryDigit(s.lookAhead(2)) {
+			return s.scanBinaryNumber()
 		} else if nextCh >= '0' && nextCh <= '7' {
 			return s.scanOctalNumber()
 		}
@@ -258,7 +552,7 @@ func (s *Lexer) scanDecimalNumber() Token {
 			ch = s.next()
 		}
 	}
-	return Token{NUMBER, s.src[s.start:s.cursor]}
+	return Token{Type: NUMBER, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanHexNumber() Token {
@@ -267,7 +561,16 @@ func (s *Lexer) scanHexNumber() Token {
 	for isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F') {
 		ch = s.next()
 	}
-	return Token{NUMBER, s.src[s.start:s.cursor]}
+	return Token{Type: NUMBER, Value: s.src[s.start:s.cursor]}
+}
+
+func (s *Lexer) scanBinaryNumber() Token {
+	ch := s.nextBy(2) // consume the leading 0b
+
+	for isBinaryDigit(ch) {
+		ch = s.next()
+	}
+	return Token{Type: NUMBER, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanOctalNumber() Token {
@@ -276,12 +579,22 @@ func (s *Lexer) scanOctalNumber() Token {
 	for '0' <= ch && ch <= '7' {
 		ch = s.next()
 	}
-	return Token{NUMBER, s.src[s.start:s.cursor]}
+	return Token{Type: NUMBER, Value: s.src[s.start:s.cursor]}
 }
 
-func (s *Lexer) scanString() Token {
+// scanString scans a string literal, preceded by a prefix of the given length, e.g. bigquery r'raw string'.
+func (s *Lexer) scanString(prefixLength int) Token {
 	s.start = s.cursor
-	ch := s.next() // consume the opening quote
+	// backslashes do not escape in raw strings
+	backslashEscapes := s.dialect.BackslashEscapes && !strings.ContainsAny(s.src[s.cursor:s.cursor+prefixLength], "rR")
+	quote := s.lookAhead(prefixLength)
+	ch := s.nextBy(prefixLength + 1) // consume the prefix and the opening quote
+	tripleQuoted := false
+	if s.dialect.TripleQuotedStrings && ch == quote && s.lookAhead(1) == quote {
+		// e.g. bigquery '''it's'''
+		tripleQuoted = true
+		ch = s.nextBy(2)
+	}
 	escaped := false
 
 	for {
@@ -293,38 +606,95 @@ func (s *Lexer) scanString() Token {
 			continue
 		}
 
-		if ch == '\\' {
+		if ch == '\\' && backslashEscapes {
 			escaped = true
 			ch = s.next()
 			continue
 		}
 
-		if ch == '\'' {
-			s.next() // consume the closing quote
-			return Token{STRING, s.src[s.start:s.cursor]}
+		if ch == quote {
+			if !tripleQuoted && s.lookAhead(1) == quote {
+				// a doubled quote is a quote in the string, e.g. 'it''s'
+				ch = s.nextBy(2)
+				continue
+			}
+			if !tripleQuoted {
+				s.next() // consume the closing quote
+				return Token{Type: STRING, Value: s.src[s.start:s.cursor]}
+			}
+			if s.lookAhead(1) == quote && s.lookAhead(2) == quote {
+				s.nextBy(3) // consume the closing quotes
+				return Token{Type: STRING, Value: s.src[s.start:s.cursor]}
+			}
 		}
 
 		if isEOF(ch) {
 			// encountered EOF before closing quote
 			// this usually happens when the string is truncated
-			return Token{INCOMPLETE_STRING, s.src[s.start:s.cursor]}
+			return Token{Type: INCOMPLETE_STRING, Value: s.src[s.start:s.cursor]}
 		}
 		ch = s.next()
 	}
 }
 
 func (s *Lexer) scanIdentifier(ch rune) Token {
-	// NOTE: this func does not distinguish between SQL keywords and identifiers
+	// NOTE: keywords are scanned as identifiers, see Keyword for their uppercase form
 	s.start = s.cursor
-	ch = s.nextBy(utf8.RuneLen(ch))
-	for isLetter(ch) || isDigit(ch) || ch == '.' || ch == '?' || ch == '$' || ch == '#' || ch == '/' {
-		ch = s.nextBy(utf8.RuneLen(ch))
+	ch, quoted, ok := s.scanQualifiedName(s.nextBy(utf8.RuneLen(ch)))
+	if !ok {
+		s.errKind = UnterminatedQuotedIdentifier
+		return Token{Type: ERROR, Value: s.src[s.start:s.cursor]}
+	}
+	if quoted {
+		return Token{Type: QUOTED_IDENT, Value: s.src[s.start:s.cursor]}
 	}
+	value := s.src[s.start:s.cursor]
 	if ch == '(' {
 		// if the identifier is followed by a (, then it's a function
-		return Token{FUNCTION, s.src[s.start:s.cursor]}
+		return Token{Type: FUNCTION, Value: value}
 	}
-	return Token{IDENT, s.src[s.start:s.cursor]}
+	return Token{Type: IDENT, Value: value}
+}
+
+// scanQualifiedName consumes the rest of a qualified name, whose parts can be quoted or not,
+// e.g. postgres public."users" or sqlserver dbo.[users].
+// It returns the rune at the cursor, whether a quoted part was consumed,
+// and false if the input ends inside a quoted part.
+func (s *Lexer) scanQualifiedName(ch rune) (rune, bool, bool) {
+	quoted := false
+	for {
+		for isLetter(ch) || isDigit(ch) || ch == '.' || ch == '?' || ch == '$' || ch == '#' || ch == '/' {
+			ch = s.nextBy(utf8.RuneLen(ch))
+		}
+		if s.src[s.cursor-1] != '.' || !s.dialect.isIdentifierQuote(ch) {
+			return ch, quoted, true
+		}
+		if !s.scanQuotedPart(ch) {
+			return ch, quoted, false
+		}
+		quoted = true
+		if ch = s.peek(); ch != '.' {
+			return ch, quoted, true
+		}
+	}
+}
+
+// scanQuotedPart consumes a quoted part of an identifier, from the opening quote to the closing quote.
+// It returns false if the input ends before the closing quote.
+func (s *Lexer) scanQuotedPart(delimiter rune) bool {
+	closingDelimiter := delimiter
+	if delimiter == '[' {
+		closingDelimiter = ']'
+	}
+	ch := s.next() // consume the opening quote
+	for ch != closingDelimiter {
+		if isEOF(ch) {
+			return false
+		}
+		ch = s.next()
+	}
+	s.next() // consume the closing quote
+	return true
 }
 
 func (s *Lexer) scanDoubleQuotedIdentifier(delimiter rune) Token {
@@ -349,12 +719,20 @@ func (s *Lexer) scanDoubleQuotedIdentifier(delimiter rune) Token {
 			break
 		}
 		if isEOF(ch) {
-			return Token{ERROR, s.src[s.start:s.cursor]}
+			s.errKind = UnterminatedQuotedIdentifier
+			return Token{Type: ERROR, Value: s.src[s.start:s.cursor]}
 		}
 		ch = s.next()
 	}
-	s.next() // consume the closing quote
-	return Token{QUOTED_IDENT, s.src[s.start:s.cursor]}
+	ch = s.next() // consume the closing quote
+	if ch == '.' && isLetter(s.lookAhead(1)) {
+		// the quoted part is followed by an unquoted one, e.g. postgres "public".users
+		if _, _, ok := s.scanQualifiedName(ch); !ok {
+			s.errKind = UnterminatedQuotedIdentifier
+			return Token{Type: ERROR, Value: s.src[s.start:s.cursor]}
+		}
+	}
+	return Token{Type: QUOTED_IDENT, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanWhitespace() Token {
@@ -364,7 +742,7 @@ func (s *Lexer) scanWhitespace() Token {
 	for isWhitespace(ch) {
 		ch = s.next()
 	}
-	return Token{WS, s.src[s.start:s.cursor]}
+	return Token{Type: WS, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanOperator(lastCh rune) Token {
@@ -375,22 +753,22 @@ func (s *Lexer) scanOperator(lastCh rune) Token {
 		lastCh = ch
 		ch = s.next()
 	}
-	return Token{OPERATOR, s.src[s.start:s.cursor]}
+	return Token{Type: OPERATOR, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanWildcard() Token {
 	s.start = s.cursor
 	s.next()
-	return Token{WILDCARD, s.src[s.start:s.cursor]}
+	return Token{Type: WILDCARD, Value: s.src[s.start:s.cursor]}
 }
 
-func (s *Lexer) scanSingleLineComment() Token {
+func (s *Lexer) scanSingleLineComment(prefixLength int) Token {
 	s.start = s.cursor
-	ch := s.nextBy(2) // consume the opening dashes
+	ch := s.nextBy(prefixLength) // consume the opening dashes or hash
 	for ch != '\n' && !isEOF(ch) {
 		ch = s.next()
 	}
-	return Token{COMMENT, s.src[s.start:s.cursor]}
+	return Token{Type: COMMENT, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanMultiLineComment() Token {
@@ -404,17 +782,36 @@ func (s *Lexer) scanMultiLineComment() Token {
 		if isEOF(ch) {
 			// encountered EOF before closing comment
 			// this usually happens when the comment is truncated
-			return Token{ERROR, s.src[s.start:s.cursor]}
+			s.errKind = UnterminatedComment
+			return Token{Type: ERROR, Value: s.src[s.start:s.cursor]}
 		}
 		ch = s.next()
 	}
-	return Token{MULTILINE_COMMENT, s.src[s.start:s.cursor]}
+	return Token{Type: MULTILINE_COMMENT, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanPunctuation() Token {
 	s.start = s.cursor
 	s.next()
-	return Token{PUNCTUATION, s.src[s.start:s.cursor]}
+	return Token{Type: PUNCTUATION, Value: s.src[s.start:s.cursor]}
+}
+
+// dollarQuoteTagLength returns the length of the dollar quote tag at the cursor, e.g. 5 for $tag$ or 2 for $$,
+// or 0 if the dollar sign does not open a tag, e.g. the sqlite parameter $name.
+func (s *Lexer) dollarQuoteTagLength() int {
+	input := s.src[s.cursor:]
+	i := 1 // skip the opening dollar sign
+	for i < len(input) && isAlphaNumeric(rune(input[i])) {
+		if i == 1 && isDigit(rune(input[i])) {
+			// a tag does not start with a digit
+			return 0
+		}
+		i++
+	}
+	if i >= len(input) || input[i] != '$' {
+		return 0
+	}
+	return i + 1
 }
 
 func (s *Lexer) scanDollarQuotedString() Token {
@@ -432,25 +829,26 @@ func (s *Lexer) scanDollarQuotedString() Token {
 		if s.matchAt([]rune(tag)) {
 			s.nextBy(len(tag)) // consume the closing tag
 			if tag == "$func$" {
-				return Token{DOLLAR_QUOTED_FUNCTION, s.src[s.start:s.cursor]}
+				return Token{Type: DOLLAR_QUOTED_FUNCTION, Value: s.src[s.start:s.cursor]}
 			}
-			return Token{DOLLAR_QUOTED_STRING, s.src[s.start:s.cursor]}
+			return Token{Type: DOLLAR_QUOTED_STRING, Value: s.src[s.start:s.cursor]}
 		}
 		s.next()
 	}
-	return Token{ERROR, s.src[s.start:s.cursor]}
+	s.errKind = UnterminatedDollarQuotedString
+	return Token{Type: ERROR, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanPositionalParameter() Token {
 	s.start = s.cursor
-	ch := s.nextBy(2) // consume the dollar sign and the number
+	ch := s.nextBy(2) // consume the dollar sign or the question mark, and the number
 	for {
 		if !isDigit(ch) {
 			break
 		}
 		ch = s.next()
 	}
-	return Token{POSITIONAL_PARAMETER, s.src[s.start:s.cursor]}
+	return Token{Type: POSITIONAL_PARAMETER, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanBindParameter() Token {
@@ -462,7 +860,86 @@ func (s *Lexer) scanBindParameter() Token {
 		}
 		ch = s.next()
 	}
-	return Token{BIND_PARAMETER, s.src[s.start:s.cursor]}
+	return Token{Type: BIND_PARAMETER, Value: s.src[s.start:s.cursor]}
+}
+
+// placeholderLength returns the length of the longest placeholder at the cursor the literals of the input were
+// replaced with, including its number if any, e.g. $2 for $, or 0 if there is none.
+// A placeholder is not followed by a letter or a digit, e.g. :status does not start with the placeholder :s.
+func (s *Lexer) placeholderLength() int {
+	input := s.src[s.cursor:]
+	length := 0
+	for _, placeholder := range s.config.placeholders {
+		if placeholder == "" || !strings.HasPrefix(input, placeholder) {
+			continue
+		}
+		end := len(placeholder)
+		for end < len(input) && isDigit(rune(input[end])) {
+			end++
+		}
+		if end < len(input) && (input[end] >= utf8.RuneSelf || isAlphaNumeric(rune(input[end]))) {
+			continue
+		}
+		length = maxInt(length, end)
+	}
+	return length
+}
+
+func (s *Lexer) scanPlaceholder() Token {
+	s.start = s.cursor
+	s.nextBy(s.placeholderLength())
+	return Token{Type: BIND_PARAMETER, Value: s.src[s.start:s.cursor]}
+}
+
+// bracedParameterLength returns the length of the braced parameter at the cursor, e.g. {name:String},
+// or 0 if the brace does not open a parameter.
+func (s *Lexer) bracedParameterLength() int {
+	input := s.src[s.cursor:]
+	i := 1 // skip the opening brace
+	for i < len(input) && isAlphaNumeric(rune(input[i])) {
+		i++
+	}
+	if i == 1 || i >= len(input) || input[i] != ':' {
+		return 0
+	}
+	end := strings.IndexAny(input[i:], "{}\n")
+	if end < 0 || input[i+end] != '}' {
+		return 0
+	}
+	return i + end + 1
+}
+
+func (s *Lexer) scanBracedParameter() Token {
+	s.start = s.cursor
+	s.nextBy(s.bracedParameterLength()) // consume the name, the type and the braces
+	return Token{Type: BIND_PARAMETER, Value: s.src[s.start:s.cursor]}
+}
+
+func (s *Lexer) scanLambdaArrow() Token {
+	s.start = s.cursor
+	s.nextBy(2) // consume the arrow
+	return Token{Type: PUNCTUATION, Value: s.src[s.start:s.cursor]}
+}
+
+// scanFormatData scans the rest of the input, which is the data of an INSERT ... FORMAT statement.
+func (s *Lexer) scanFormatData() Token {
+	s.start = s.cursor
+	s.nextBy(len(s.src) - s.cursor)
+	s.formatData = false
+	return Token{Type: FORMAT_DATA, Value: s.src[s.start:s.cursor]}
+}
+
+// scanClientCommand scans a command of the client, which runs until the end of the line.
+// The trailing spaces are left to the whitespace token that follows.
+func (s *Lexer) scanClientCommand() Token {
+	s.start = s.cursor
+	end := strings.IndexByte(s.src[s.cursor:], '\n')
+	if end < 0 {
+		end = len(s.src) - s.cursor
+	}
+	command := strings.TrimRight(s.src[s.cursor:s.cursor+end], " \t\r")
+	s.nextBy(len(command))
+	return Token{Type: CLIENT_COMMAND, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanSystemVariable() Token {
@@ -476,14 +953,18 @@ func (s *Lexer) scanSystemVariable() Token {
 	}
 	if s.cursor-s.start == 2 {
 		// if the system variable is just @@, then it's a jsonpath operator
-		return Token{OPERATOR, s.src[s.start:s.cursor]}
+		return Token{Type: OPERATOR, Value: s.src[s.start:s.cursor]}
 	}
-	return Token{SYSTEM_VARIABLE, s.src[s.start:s.cursor]}
+	return Token{Type: SYSTEM_VARIABLE, Value: s.src[s.start:s.cursor]}
 }
 
 func (s *Lexer) scanUnknown() Token {
 	// When we see an unknown token, we advance the cursor until we see something that looks like a token boundary.
 	s.start = s.cursor
-	s.next()
-	return Token{UNKNOWN, s.src[s.start:s.cursor]}
+	r, size := utf8.DecodeRuneInString(s.src[s.cursor:])
+	if r == utf8.RuneError && size == 1 {
+		s.errKind = InvalidUTF8
+	}
+	s.nextBy(size) // consume the whole rune
+	return Token{Type: UNKNOWN, Value: s.src[s.start:s.cursor]}
 }
@@ -94,5 +94,15 @@ GROUP BY sales.product_key;
 				lexer.ScanAll()
 			}
 		})
+		b.Run(bm.name+"/Reused/"+strconv.Itoa(len(bm.query)), func(b *testing.B) {
+			lexer := New("")
+			var tokens []Token
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				lexer.Reset(bm.query)
+				tokens = lexer.ScanInto(tokens[:0])
+			}
+		})
 	}
 }
@@ -0,0 +1,42 @@
+package sqllexer
+
+import "fmt"
+
+// ErrorKind describes the kind of problem the lexer encountered while scanning.
+type ErrorKind int
+
+const (
+	noError ErrorKind = iota
+	UnterminatedComment
+	UnterminatedQuotedIdentifier
+	UnterminatedDollarQuotedString
+	InvalidUTF8
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case UnterminatedComment:
+		return "unterminated comment"
+	case UnterminatedQuotedIdentifier:
+		return "unterminated quoted identifier"
+	case UnterminatedDollarQuotedString:
+		return "unterminated dollar quoted string"
+	case InvalidUTF8:
+		return "invalid UTF-8"
+	default:
+		return "unknown error"
+	}
+}
+
+// LexError is a problem the lexer encountered while scanning.
+// The lexer never stops on an error, it records it and keeps scanning on a best effort basis.
+type LexError struct {
+	Kind    ErrorKind
+	Start   Position // position of the first character of the offending token
+	End     Position // position immediately after the last character of the offending token
+	Partial string   // the text scanned before the lexer gave up
+}
+
+func (e *LexError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d", e.Kind, e.Start.Line, e.Start.Column)
+}
@@ -0,0 +1,38 @@
+//go:build go1.23
+
+package sqllexer
+
+import "iter"
+
+// Tokens returns an iterator over the tokens of the rest of the input, scanned as the loop asks for them.
+// Unlike ScanAllTokens, no goroutine is involved, so breaking out of the loop simply stops the scanning.
+func (s *Lexer) Tokens() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			token := s.Scan()
+			if token.Type == EOF {
+				// don't include EOF token in the result
+				return
+			}
+			if !yield(token) {
+				return
+			}
+		}
+	}
+}
+
+// Tokens returns an iterator over the tokens of the rest of the input, read and scanned as the loop asks for them.
+func (r *ReaderLexer) Tokens() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			token := r.Scan()
+			if token.Type == EOF {
+				// don't include EOF token in the result
+				return
+			}
+			if !yield(token) {
+				return
+			}
+		}
+	}
+}
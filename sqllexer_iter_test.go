@@ -0,0 +1,35 @@
+//go:build go1.23
+
+package sqllexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexerTokens(t *testing.T) {
+	input := "SELECT * FROM users WHERE name = 'alice' -- comment"
+
+	var tokens []Token
+	for token := range New(input).Tokens() {
+		tokens = append(tokens, token)
+	}
+	assert.Equal(t, New(input).ScanAll(), tokens)
+
+	tokens = nil
+	for token := range NewReaderLexer(strings.NewReader(input)).Tokens() {
+		tokens = append(tokens, token)
+	}
+	assert.Equal(t, New(input).ScanAll(), tokens)
+
+	// breaking out of the loop stops the scanning where it is
+	lexer := New(input)
+	for token := range lexer.Tokens() {
+		if lexer.Keyword(token) == "FROM" {
+			break
+		}
+	}
+	assert.Equal(t, Token{Type: WS, Value: " ", Offset: 13}, lexer.Scan())
+}
@@ -1,6 +1,7 @@
 package sqllexer
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -18,485 +19,497 @@ func TestLexer(t *testing.T) {
 			name:  "simple select with number",
 			input: "SELECT * FROM users where id = 1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
 			},
 		},
 		{
 			name:  "simple select with number",
 			input: "SELECT * FROM users where id = '1'",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{STRING, "'1'"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'1'"},
 			},
 		},
 		{
 			name:  "simple select with negative number",
 			input: "SELECT * FROM users where id = -1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "-1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "-1"},
 			},
 		},
 		{
 			name:  "simple select with string",
 			input: "SELECT * FROM users where id = '12'",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{STRING, "'12'"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'12'"},
 			},
 		},
 		{
 			name:  "simple select with double quoted identifier",
 			input: "SELECT * FROM \"users table\" where id = 1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{QUOTED_IDENT, "\"users table\""},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "\"users table\""},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
 			},
 		},
 		{
 			name:  "simple select with single line comment",
 			input: "SELECT * FROM users where id = 1 -- comment here",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
-				{WS, " "},
-				{COMMENT, "-- comment here"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
+				{Type: WS, Value: " "},
+				{Type: COMMENT, Value: "-- comment here"},
 			},
 		},
 		{
 			name:  "simple select with multi line comment",
 			input: "SELECT * /* comment here */ FROM users where id = 1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{MULTILINE_COMMENT, "/* comment here */"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: MULTILINE_COMMENT, Value: "/* comment here */"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
 			},
 		},
 		{
 			name:  "simple malformed select",
 			input: "SELECT * FROM users where id = 1 and name = 'j",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
-				{WS, " "},
-				{IDENT, "and"},
-				{WS, " "},
-				{IDENT, "name"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{INCOMPLETE_STRING, "'j"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "and"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "name"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: INCOMPLETE_STRING, Value: "'j"},
 			},
 		},
 		{
 			name:  "truncated sql",
 			input: "SELECT * FROM users where id = ",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
 			},
 		},
 		{
 			name:  "simple select with array of literals",
 			input: "SELECT * FROM users where id in (1, '2')",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{IDENT, "in"},
-				{WS, " "},
-				{PUNCTUATION, "("},
-				{NUMBER, "1"},
-				{PUNCTUATION, ","},
-				{WS, " "},
-				{STRING, "'2'"},
-				{PUNCTUATION, ")"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "in"},
+				{Type: WS, Value: " "},
+				{Type: PUNCTUATION, Value: "("},
+				{Type: NUMBER, Value: "1"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'2'"},
+				{Type: PUNCTUATION, Value: ")"},
 			},
 		},
 		{
 			name:  "dollar quoted function",
 			input: "SELECT $func$INSERT INTO table VALUES ('a', 1, 2)$func$ FROM users",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{DOLLAR_QUOTED_FUNCTION, "$func$INSERT INTO table VALUES ('a', 1, 2)$func$"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: DOLLAR_QUOTED_FUNCTION, Value: "$func$INSERT INTO table VALUES ('a', 1, 2)$func$"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "dollar quoted string",
 			input: "SELECT * FROM users where id = $tag$test$tag$",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{DOLLAR_QUOTED_STRING, "$tag$test$tag$"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: DOLLAR_QUOTED_STRING, Value: "$tag$test$tag$"},
 			},
 		},
 		{
 			name:  "dollar quoted string",
 			input: "SELECT * FROM users where id = $$test$$",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{DOLLAR_QUOTED_STRING, "$$test$$"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: DOLLAR_QUOTED_STRING, Value: "$$test$$"},
 			},
 		},
 		{
 			name:  "numbered parameter",
 			input: "SELECT * FROM users where id = $1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{POSITIONAL_PARAMETER, "$1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: POSITIONAL_PARAMETER, Value: "$1"},
 			},
 		},
 		{
 			name:  "identifier with underscore and period",
 			input: "SELECT * FROM users where user_id = 2 and users.name = 'j'",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "user_id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "2"},
-				{WS, " "},
-				{IDENT, "and"},
-				{WS, " "},
-				{IDENT, "users.name"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{STRING, "'j'"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "user_id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "2"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "and"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users.name"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'j'"},
 			},
 		},
 		{
 			name:  "select with hex and octal numbers",
 			input: "SELECT * FROM users where id = 0x123 and id = 0X123 and id = 0123",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "0x123"},
-				{WS, " "},
-				{IDENT, "and"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "0X123"},
-				{WS, " "},
-				{IDENT, "and"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "0123"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "0x123"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "and"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "0X123"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "and"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "0123"},
 			},
 		},
 		{
 			name:  "select with float numbers and scientific notation",
 			input: "SELECT 1.2,1.2e3,1.2e-3,1.2E3,1.2E-3 FROM users",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{NUMBER, "1.2"},
-				{PUNCTUATION, ","},
-				{NUMBER, "1.2e3"},
-				{PUNCTUATION, ","},
-				{NUMBER, "1.2e-3"},
-				{PUNCTUATION, ","},
-				{NUMBER, "1.2E3"},
-				{PUNCTUATION, ","},
-				{NUMBER, "1.2E-3"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1.2"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: NUMBER, Value: "1.2e3"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: NUMBER, Value: "1.2e-3"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: NUMBER, Value: "1.2E3"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: NUMBER, Value: "1.2E-3"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "select with double quoted identifier",
 			input: `SELECT * FROM "users table"`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{QUOTED_IDENT, `"users table"`},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: `"users table"`},
 			},
 		},
 		{
 			name:  "select with double quoted identifier",
 			input: `SELECT * FROM "public"."users table"`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{QUOTED_IDENT, `"public"."users table"`},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: `"public"."users table"`},
 			},
 		},
 		{
 			name:  "select with escaped string",
 			input: "SELECT * FROM users where id = 'j\\'s'",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{STRING, "'j\\'s'"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'j\\'s'"},
+			},
+		},
+		{
+			name:  "select with doubled quote in string",
+			input: "SELECT 'it''s', ''''",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'it''s'"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "''''"},
 			},
 		},
 		{
 			name:  "select with escaped string",
 			input: "SELECT * FROM users where id =?",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{OPERATOR, "?"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: OPERATOR, Value: "?"},
 			},
 		},
 		{
 			name:  "select with bind parameter",
 			input: "SELECT * FROM users where id = :id and name = :1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{BIND_PARAMETER, ":id"},
-				{WS, " "},
-				{IDENT, "and"},
-				{WS, " "},
-				{IDENT, "name"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{BIND_PARAMETER, ":1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: ":id"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "and"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "name"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: ":1"},
 			},
 			lexerOpts: []lexerOption{WithDBMS(DBMSOracle)},
 		},
@@ -504,63 +517,63 @@ func TestLexer(t *testing.T) {
 			name:  "select with bind parameter",
 			input: "SELECT * FROM users where id = @id and name = @1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{BIND_PARAMETER, "@id"},
-				{WS, " "},
-				{IDENT, "and"},
-				{WS, " "},
-				{IDENT, "name"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{BIND_PARAMETER, "@1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: "@id"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "and"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "name"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: "@1"},
 			},
 		},
 		{
 			name:  "select with system variable",
 			input: "SELECT @@VERSION AS SqlServerVersion",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{SYSTEM_VARIABLE, "@@VERSION"},
-				{WS, " "},
-				{IDENT, "AS"},
-				{WS, " "},
-				{IDENT, "SqlServerVersion"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: SYSTEM_VARIABLE, Value: "@@VERSION"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "AS"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "SqlServerVersion"},
 			},
 		},
 		{
 			name:  "SQL Server quoted identifier",
 			input: "SELECT [user] FROM [test].[table] WHERE [id] = 1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{QUOTED_IDENT, "[user]"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{QUOTED_IDENT, "[test].[table]"},
-				{WS, " "},
-				{IDENT, "WHERE"},
-				{WS, " "},
-				{QUOTED_IDENT, "[id]"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "[user]"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "[test].[table]"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "WHERE"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "[id]"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
 			},
 			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
 		},
@@ -568,51 +581,300 @@ func TestLexer(t *testing.T) {
 			name:  "MySQL backtick quoted identifier",
 			input: "SELECT `user` FROM `test`.`table` WHERE `id` = 1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{QUOTED_IDENT, "`user`"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{QUOTED_IDENT, "`test`.`table`"},
-				{WS, " "},
-				{IDENT, "WHERE"},
-				{WS, " "},
-				{QUOTED_IDENT, "`id`"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "`user`"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "`test`.`table`"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "WHERE"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "`id`"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
+		},
+		{
+			name:  "qualified identifier mixing quoted and unquoted parts",
+			input: `SELECT * FROM public."users" JOIN "sales".orders ON 1`,
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: `public."users"`},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "JOIN"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: `"sales".orders`},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "ON"},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
+			},
+		},
+		{
+			name:  "SQL Server four-part name mixing quoted and unquoted parts",
+			input: "SELECT * FROM srv.[my db].dbo.[users]",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "srv.[my db].dbo.[users]"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			name:  "MySQL qualified identifier mixing quoted and unquoted parts",
+			input: "SELECT * FROM db.`order`",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "db.`order`"},
 			},
 			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
 		},
+		{
+			name:  "BigQuery strings",
+			input: `SELECT r'\d+', b"abc", '''it's'''`,
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: `r'\d+'`},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: `b"abc"`},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: `'''it's'''`},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSBigQuery)},
+		},
+		{
+			name:  "BigQuery parameterized types",
+			input: "CAST(x AS ARRAY<INT64>) > 1",
+			expected: []Token{
+				{Type: FUNCTION, Value: "CAST"},
+				{Type: PUNCTUATION, Value: "("},
+				{Type: IDENT, Value: "x"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "AS"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "ARRAY"},
+				{Type: PUNCTUATION, Value: "<"},
+				{Type: IDENT, Value: "INT64"},
+				{Type: PUNCTUATION, Value: ">"},
+				{Type: PUNCTUATION, Value: ")"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: ">"},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSBigQuery)},
+		},
+		{
+			name:  "ClickHouse query parameters and lambda",
+			input: "SELECT arrayMap(x -> x + {n:UInt8}, {ids:Array(UInt32)})",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: FUNCTION, Value: "arrayMap"},
+				{Type: PUNCTUATION, Value: "("},
+				{Type: IDENT, Value: "x"},
+				{Type: WS, Value: " "},
+				{Type: PUNCTUATION, Value: "->"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "x"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "+"},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: "{n:UInt8}"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: "{ids:Array(UInt32)}"},
+				{Type: PUNCTUATION, Value: ")"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSClickHouse)},
+		},
+		{
+			name:  "ClickHouse insert with format data",
+			input: "INSERT INTO t FORMAT CSV\n1,'a'\n2,'b'",
+			expected: []Token{
+				{Type: IDENT, Value: "INSERT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "INTO"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "t"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FORMAT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "CSV"},
+				{Type: WS, Value: "\n"},
+				{Type: FORMAT_DATA, Value: "1,'a'\n2,'b'"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSClickHouse)},
+		},
+		{
+			name:  "ClickHouse select with format",
+			input: "SELECT 1 FORMAT CSV",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FORMAT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "CSV"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSClickHouse)},
+		},
+		{
+			name:  "dollar sign parameter is not a dollar quoted string",
+			input: "SELECT * FROM users WHERE id = $id AND name = 'x'",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "WHERE"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: "$id"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "AND"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "name"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'x'"},
+			},
+		},
+		{
+			name:  "SQLite parameters and blob literal",
+			input: "SELECT [data] FROM t WHERE id = ?1 AND blob = X'0aff' AND a = :a",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: QUOTED_IDENT, Value: "[data]"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "t"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "WHERE"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: POSITIONAL_PARAMETER, Value: "?1"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "AND"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "blob"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "X'0aff'"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "AND"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "a"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: BIND_PARAMETER, Value: ":a"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLite)},
+		},
+		{
+			name:  "MySQL hex and binary literals",
+			input: "SELECT 0x1F, X'1F', 0b0101, b'0101', 0b2",
+			expected: []Token{
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "0x1F"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "X'1F'"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "0b0101"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "b'0101'"},
+				{Type: PUNCTUATION, Value: ","},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "0"},
+				{Type: IDENT, Value: "b2"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
+		},
+		{
+			name:  "Teradata BTEQ command",
+			input: ".LOGON tdprod/etl_user,secret  \nSEL a.b FROM t",
+			expected: []Token{
+				{Type: CLIENT_COMMAND, Value: ".LOGON tdprod/etl_user,secret"},
+				{Type: WS, Value: "  \n"},
+				{Type: IDENT, Value: "SEL"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "a.b"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "t"},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSTeradata)},
+		},
 		{
 			name:  "Tokenize function",
 			input: "SELECT count(*) FROM users",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{FUNCTION, "count"},
-				{PUNCTUATION, "("},
-				{WILDCARD, "*"},
-				{PUNCTUATION, ")"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: FUNCTION, Value: "count"},
+				{Type: PUNCTUATION, Value: "("},
+				{Type: WILDCARD, Value: "*"},
+				{Type: PUNCTUATION, Value: ")"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "Tokenize temp table",
 			input: `SELECT * FROM #temp`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "#temp"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "#temp"},
 			},
 			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
 		},
@@ -620,15 +882,15 @@ func TestLexer(t *testing.T) {
 			name:  "MySQL comment",
 			input: `SELECT * FROM users # comment`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
-				{WS, " "},
-				{COMMENT, "# comment"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
+				{Type: WS, Value: " "},
+				{Type: COMMENT, Value: "# comment"},
 			},
 			lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)},
 		},
@@ -636,190 +898,190 @@ func TestLexer(t *testing.T) {
 			name:  "drop table if exists",
 			input: `DROP TABLE IF EXISTS users`,
 			expected: []Token{
-				{IDENT, "DROP"},
-				{WS, " "},
-				{IDENT, "TABLE"},
-				{WS, " "},
-				{IDENT, "IF"},
-				{WS, " "},
-				{IDENT, "EXISTS"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "DROP"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "TABLE"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "IF"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "EXISTS"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "select only",
 			input: "SELECT * FROM ONLY tab1 where id = 1",
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{WILDCARD, "*"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "ONLY"},
-				{WS, " "},
-				{IDENT, "tab1"},
-				{WS, " "},
-				{IDENT, "where"},
-				{WS, " "},
-				{IDENT, "id"},
-				{WS, " "},
-				{OPERATOR, "="},
-				{WS, " "},
-				{NUMBER, "1"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: WILDCARD, Value: "*"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "ONLY"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "tab1"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "where"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "id"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "="},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "1"},
 			},
 		},
 		{
 			name:  "extracts n'th element of JSON array ",
 			input: `SELECT data::json -> 2 FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "json"},
-				{WS, " "},
-				{OPERATOR, "->"},
-				{WS, " "},
-				{NUMBER, "2"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "json"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "->"},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "2"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "extracts JSON object field with the given key",
 			input: `SELECT data::json -> 'key' FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "json"},
-				{WS, " "},
-				{OPERATOR, "->"},
-				{WS, " "},
-				{STRING, "'key'"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "json"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "->"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'key'"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "extracts n'th element of JSON array, as text",
 			input: `SELECT data::json ->> 2 FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "json"},
-				{WS, " "},
-				{OPERATOR, "->>"},
-				{WS, " "},
-				{NUMBER, "2"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "json"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "->>"},
+				{Type: WS, Value: " "},
+				{Type: NUMBER, Value: "2"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "extracts JSON object field with the given key, as text",
 			input: `SELECT data::json ->> 'key' FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "json"},
-				{WS, " "},
-				{OPERATOR, "->>"},
-				{WS, " "},
-				{STRING, "'key'"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "json"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "->>"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'key'"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "extracts JSON sub-object at the specified path",
 			input: `SELECT data::json #> '{key1,key2}' FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "json"},
-				{WS, " "},
-				{OPERATOR, "#>"},
-				{WS, " "},
-				{STRING, "'{key1,key2}'"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "json"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "#>"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'{key1,key2}'"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "extracts JSON sub-object at the specified path as text",
 			input: `SELECT data::json #>> '{key1,key2}' FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "json"},
-				{WS, " "},
-				{OPERATOR, "#>>"},
-				{WS, " "},
-				{STRING, "'{key1,key2}'"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "json"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "#>>"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'{key1,key2}'"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "JSON path return any item for the specified JSON value",
 			input: `SELECT data::jsonb @? '$.a[*] ? (@ > 2)' FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "jsonb"},
-				{WS, " "},
-				{OPERATOR, "@?"},
-				{WS, " "},
-				{STRING, "'$.a[*] ? (@ > 2)'"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "jsonb"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "@?"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'$.a[*] ? (@ > 2)'"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 		{
 			name:  "JSON path predicate check for the specified JSON value",
 			input: `SELECT data::jsonb @@ '$.a[*] > 2' FROM users`,
 			expected: []Token{
-				{IDENT, "SELECT"},
-				{WS, " "},
-				{IDENT, "data"},
-				{OPERATOR, "::"},
-				{IDENT, "jsonb"},
-				{WS, " "},
-				{OPERATOR, "@@"},
-				{WS, " "},
-				{STRING, "'$.a[*] > 2'"},
-				{WS, " "},
-				{IDENT, "FROM"},
-				{WS, " "},
-				{IDENT, "users"},
+				{Type: IDENT, Value: "SELECT"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "data"},
+				{Type: OPERATOR, Value: "::"},
+				{Type: IDENT, Value: "jsonb"},
+				{Type: WS, Value: " "},
+				{Type: OPERATOR, Value: "@@"},
+				{Type: WS, Value: " "},
+				{Type: STRING, Value: "'$.a[*] > 2'"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "FROM"},
+				{Type: WS, Value: " "},
+				{Type: IDENT, Value: "users"},
 			},
 		},
 	}
@@ -828,7 +1090,7 @@ func TestLexer(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			lexer := New(tt.input, tt.lexerOpts...)
 			tokens := lexer.ScanAll()
-			assert.Equal(t, tt.expected, tokens)
+			assert.Equal(t, tt.expected, withoutPositions(tokens))
 		})
 	}
 }
@@ -842,43 +1104,43 @@ func TestLexerUnicode(t *testing.T) {
 		{
 			input: `Descripció_CAT`,
 			expected: []Token{
-				{IDENT, `Descripció_CAT`},
+				{Type: IDENT, Value: `Descripció_CAT`},
 			},
 		},
 		{
 			input: `世界`,
 			expected: []Token{
-				{IDENT, `世界`},
+				{Type: IDENT, Value: `世界`},
 			},
 		},
 		{
 			input: `こんにちは`,
 			expected: []Token{
-				{IDENT, `こんにちは`},
+				{Type: IDENT, Value: `こんにちは`},
 			},
 		},
 		{
 			input: `안녕하세요`,
 			expected: []Token{
-				{IDENT, `안녕하세요`},
+				{Type: IDENT, Value: `안녕하세요`},
 			},
 		},
 		{
 			input: `über`,
 			expected: []Token{
-				{IDENT, `über`},
+				{Type: IDENT, Value: `über`},
 			},
 		},
 		{
 			input: `résumé`,
 			expected: []Token{
-				{IDENT, `résumé`},
+				{Type: IDENT, Value: `résumé`},
 			},
 		},
 		{
 			input: `"über"`,
 			expected: []Token{
-				{QUOTED_IDENT, `"über"`},
+				{Type: QUOTED_IDENT, Value: `"über"`},
 			},
 		},
 	}
@@ -887,15 +1149,410 @@ func TestLexerUnicode(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			lexer := New(tt.input, tt.lexerOpts...)
 			tokens := lexer.ScanAll()
-			assert.Equal(t, tt.expected, tokens)
+			assert.Equal(t, tt.expected, withoutPositions(tokens))
+		})
+	}
+}
+
+func TestLexerKeywords(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  []string // the keyword of each token
+		lexerOpts []lexerOption
+	}{
+		{
+			input:    "select id from users",
+			expected: []string{"SELECT", "", "", "", "FROM", "", ""},
+		},
+		{
+			input:    "Insert Into t(a) VALUES (1)",
+			expected: []string{"INSERT", "", "INTO", "", "", "", "", "", "", "VALUES", "", "", "", ""},
+		},
+		{
+			input:    "SELECT COUNT(*) FROM \"select\" WHERE deleted IS NULL",
+			expected: []string{"SELECT", "", "", "", "", "", "", "FROM", "", "", "", "WHERE", "", "", "", "IS", "", ""},
+		},
+		{
+			// the values are not keywords
+			input:    "SELECT TRUE, CURRENT_DATE",
+			expected: []string{"SELECT", "", "", "", "", ""},
+		},
+		{
+			input:     "SEL id FROM t",
+			expected:  []string{"SELECT", "", "", "", "FROM", "", ""},
+			lexerOpts: []lexerOption{WithDBMS(DBMSTeradata)},
+		},
+		{
+			input:    "SELECT selection, from_date",
+			expected: []string{"SELECT", "", "", "", "", ""},
+		},
+		{
+			input:     "SELECT @@version",
+			expected:  []string{"SELECT", "", ""},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := New(tt.input, tt.lexerOpts...)
+			tokens := lexer.ScanAll()
+			keywords := make([]string, len(tokens))
+			for i, token := range tokens {
+				keywords[i] = lexer.Keyword(token)
+				id, ok := LookupKeyword(keywords[i])
+				if ok {
+					assert.Equal(t, id, token.KeywordID)
+				} else {
+					assert.Zero(t, token.KeywordID)
+				}
+			}
+			assert.Equal(t, tt.expected, keywords)
+		})
+	}
+}
+
+func TestLookupKeyword(t *testing.T) {
+	id, ok := LookupKeyword("select")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT", id.String())
+
+	for _, word := range []string{"users", "NULL", "CURRENT_DATE", "", "SEL"} {
+		id, ok := LookupKeyword(word)
+		assert.False(t, ok, word)
+		assert.Zero(t, id, word)
+	}
+	assert.Equal(t, "", KeywordID(0).String())
+}
+
+func TestLexerUnquote(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		ok        bool
+		lexerOpts []lexerOption
+	}{
+		{input: "'abc'", expected: "abc", ok: true},
+		{input: "'it''s'", expected: "it's", ok: true},
+		{input: "''", expected: "", ok: true},
+		{input: `'a\nb'`, expected: `a\nb`, ok: true, lexerOpts: []lexerOption{WithDBMS(DBMSSQLite)}},
+		{input: `'a\nb'`, expected: "a\nb", ok: true, lexerOpts: []lexerOption{WithDBMS(DBMSMySQL)}},
+		{input: "x'0A'", expected: "0A", ok: true, lexerOpts: []lexerOption{WithDBMS(DBMSSQLite)}},
+		{input: `r'\d+'`, expected: `\d+`, ok: true, lexerOpts: []lexerOption{WithDBMS(DBMSBigQuery)}},
+		{input: `'''it's'''`, expected: "it's", ok: true, lexerOpts: []lexerOption{WithDBMS(DBMSBigQuery)}},
+		{input: "$tag$it's$tag$", expected: "it's", ok: true, lexerOpts: []lexerOption{WithDBMS(DBMSPostgres)}},
+		{input: "'abc", expected: "", ok: false},
+		{input: "abc", expected: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := New(tt.input, tt.lexerOpts...)
+			got, ok := lexer.Unquote(lexer.Scan())
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+// positionedToken is a token along with the positions of its value.
+type positionedToken struct {
+	Token
+	start, end Position
+}
+
+func TestLexerPositions(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  []positionedToken
+		lexerOpts []lexerOption
+	}{
+		{
+			input: "SELECT id",
+			expected: []positionedToken{
+				{Token{Type: IDENT, Value: "SELECT", Offset: 0}, Position{0, 1, 1}, Position{6, 1, 7}},
+				{Token{Type: WS, Value: " ", Offset: 6}, Position{6, 1, 7}, Position{7, 1, 8}},
+				{Token{Type: IDENT, Value: "id", Offset: 7}, Position{7, 1, 8}, Position{9, 1, 10}},
+			},
+		},
+		{
+			input: "SELECT *\nFROM users\n  WHERE name = 'über'",
+			expected: []positionedToken{
+				{Token{Type: IDENT, Value: "SELECT", Offset: 0}, Position{0, 1, 1}, Position{6, 1, 7}},
+				{Token{Type: WS, Value: " ", Offset: 6}, Position{6, 1, 7}, Position{7, 1, 8}},
+				{Token{Type: WILDCARD, Value: "*", Offset: 7}, Position{7, 1, 8}, Position{8, 1, 9}},
+				{Token{Type: WS, Value: "\n", Offset: 8}, Position{8, 1, 9}, Position{9, 2, 1}},
+				{Token{Type: IDENT, Value: "FROM", Offset: 9}, Position{9, 2, 1}, Position{13, 2, 5}},
+				{Token{Type: WS, Value: " ", Offset: 13}, Position{13, 2, 5}, Position{14, 2, 6}},
+				{Token{Type: IDENT, Value: "users", Offset: 14}, Position{14, 2, 6}, Position{19, 2, 11}},
+				{Token{Type: WS, Value: "\n  ", Offset: 19}, Position{19, 2, 11}, Position{22, 3, 3}},
+				{Token{Type: IDENT, Value: "WHERE", Offset: 22}, Position{22, 3, 3}, Position{27, 3, 8}},
+				{Token{Type: WS, Value: " ", Offset: 27}, Position{27, 3, 8}, Position{28, 3, 9}},
+				{Token{Type: IDENT, Value: "name", Offset: 28}, Position{28, 3, 9}, Position{32, 3, 13}},
+				{Token{Type: WS, Value: " ", Offset: 32}, Position{32, 3, 13}, Position{33, 3, 14}},
+				{Token{Type: OPERATOR, Value: "=", Offset: 33}, Position{33, 3, 14}, Position{34, 3, 15}},
+				{Token{Type: WS, Value: " ", Offset: 34}, Position{34, 3, 15}, Position{35, 3, 16}},
+				{Token{Type: STRING, Value: "'über'", Offset: 35}, Position{35, 3, 16}, Position{42, 3, 22}},
+			},
+		},
+		{
+			input: "/* multi\nline */ \"users",
+			expected: []positionedToken{
+				{Token{Type: MULTILINE_COMMENT, Value: "/* multi\nline */", Offset: 0}, Position{0, 1, 1}, Position{16, 2, 8}},
+				{Token{Type: WS, Value: " ", Offset: 16}, Position{16, 2, 8}, Position{17, 2, 9}},
+				{Token{Type: ERROR, Value: "\"users", Offset: 17}, Position{17, 2, 9}, Position{23, 2, 15}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := New(tt.input, tt.lexerOpts...)
+			tokens := lexer.ScanAll()
+			var positioned []positionedToken
+			for _, token := range tokens {
+				token.KeywordID = 0 // see TestLexerKeywords
+				positioned = append(positioned, positionedToken{token, lexer.Position(token.Offset), lexer.Position(token.Offset + len(token.Value))})
+			}
+			assert.Equal(t, tt.expected, positioned)
+
+			// the positions can be resolved while the rest of the input is scanned
+			streamer := New(tt.input, tt.lexerOpts...)
+			var streamed []Token
+			var streamedPositioned []positionedToken
+			for token := range streamer.ScanAllTokens() {
+				streamed = append(streamed, token)
+				token.KeywordID = 0
+				streamedPositioned = append(streamedPositioned, positionedToken{token, streamer.Position(token.Offset), streamer.Position(token.Offset + len(token.Value))})
+			}
+			assert.Equal(t, tokens, streamed)
+			assert.Equal(t, tt.expected, streamedPositioned)
+
+			// the positions can be asked for in any order
+			for i := len(tt.expected) - 1; i >= 0; i-- {
+				assert.Equal(t, tt.expected[i].start, lexer.Position(tt.expected[i].Offset))
+			}
 		})
 	}
 }
 
+func TestScanAllTokensContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tokenCh := New("SELECT * FROM users WHERE id = 1").ScanAllTokensContext(ctx)
+	selectID, _ := LookupKeyword("SELECT")
+	assert.Equal(t, Token{Type: IDENT, KeywordID: selectID, Value: "SELECT", Offset: 0}, <-tokenCh)
+
+	// the consumer stops reading, the channel is closed rather than left blocked
+	cancel()
+	for range tokenCh {
+		// a token may already be on its way
+	}
+
+	var tokens []Token
+	for token := range New("SELECT 1").ScanAllTokensContext(context.Background()) {
+		tokens = append(tokens, token)
+	}
+	assert.Equal(t, New("SELECT 1").ScanAll(), tokens)
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  []*LexError
+		lexerOpts []lexerOption
+	}{
+		{
+			input:    "SELECT * FROM users",
+			expected: nil,
+		},
+		{
+			input: "SELECT * FROM users /* truncated",
+			expected: []*LexError{
+				{
+					Kind:    UnterminatedComment,
+					Start:   Position{20, 1, 21},
+					End:     Position{32, 1, 33},
+					Partial: "/* truncated",
+				},
+			},
+		},
+		{
+			input: "SELECT * FROM \"users",
+			expected: []*LexError{
+				{
+					Kind:    UnterminatedQuotedIdentifier,
+					Start:   Position{14, 1, 15},
+					End:     Position{20, 1, 21},
+					Partial: "\"users",
+				},
+			},
+		},
+		{
+			input: "SELECT * FROM\n[users",
+			expected: []*LexError{
+				{
+					Kind:    UnterminatedQuotedIdentifier,
+					Start:   Position{14, 2, 1},
+					End:     Position{20, 2, 7},
+					Partial: "[users",
+				},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSSQLServer)},
+		},
+		{
+			input: "SELECT $tag$abc",
+			expected: []*LexError{
+				{
+					Kind:    UnterminatedDollarQuotedString,
+					Start:   Position{7, 1, 8},
+					End:     Position{15, 1, 16},
+					Partial: "$tag$abc",
+				},
+			},
+		},
+		{
+			input: "SELECT \xff, \xfe FROM users",
+			expected: []*LexError{
+				{
+					Kind:    InvalidUTF8,
+					Start:   Position{7, 1, 8},
+					End:     Position{8, 1, 9},
+					Partial: "\xff",
+				},
+				{
+					Kind:    InvalidUTF8,
+					Start:   Position{10, 1, 11},
+					End:     Position{11, 1, 12},
+					Partial: "\xfe",
+				},
+			},
+		},
+		{
+			input: "SELECT 'a\xffb', \"caf\xe9\" FROM users",
+			expected: []*LexError{
+				{
+					Kind:    InvalidUTF8,
+					Start:   Position{7, 1, 8},
+					End:     Position{12, 1, 13},
+					Partial: "'a\xffb'",
+				},
+				{
+					Kind:    InvalidUTF8,
+					Start:   Position{14, 1, 15},
+					End:     Position{20, 1, 21},
+					Partial: "\"caf\xe9\"",
+				},
+			},
+		},
+		{
+			// the braced parameter is consumed at once rather than byte by byte
+			input: "SELECT {n\xff:String}",
+			expected: []*LexError{
+				{
+					Kind:    InvalidUTF8,
+					Start:   Position{7, 1, 8},
+					End:     Position{18, 1, 19},
+					Partial: "{n\xff:String}",
+				},
+			},
+			lexerOpts: []lexerOption{WithDBMS(DBMSClickHouse)},
+		},
+		{
+			input:    "SELECT '€'",
+			expected: nil,
+		},
+		{
+			input:    "SELECT ñame, 'café', \"€\" FROM t",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := New(tt.input, tt.lexerOpts...)
+			lexer.ScanAll()
+			assert.Equal(t, tt.expected, lexer.Errors())
+			if tt.expected == nil {
+				assert.NoError(t, lexer.Err())
+			} else {
+				assert.Equal(t, tt.expected[0], lexer.Err())
+			}
+		})
+	}
+}
+
+func TestLexerReset(t *testing.T) {
+	inputs := []string{
+		"SELECT * FROM users WHERE id = 1",
+		"SELECT 'unterminated",
+		".LOGON host/user,password\nSEL * FROM t",
+		"",
+		"SELECT * FROM [dbo].[users]",
+	}
+
+	for _, opts := range [][]lexerOption{{WithDBMS(DBMSTeradata)}, {WithDetectDBMS(true)}} {
+		lexer := New("", opts...)
+		var tokens []Token
+		for _, input := range inputs {
+			expected := New(input, opts...)
+			lexer.Reset(input)
+			tokens = lexer.ScanInto(tokens[:0])
+			if expectedTokens := expected.ScanAll(); len(expectedTokens) > 0 {
+				assert.Equal(t, expectedTokens, tokens, input)
+			} else {
+				assert.Empty(t, tokens, input)
+			}
+			assert.Equal(t, expected.Errors(), lexer.Errors(), input)
+			assert.Equal(t, expected.config.DBMS, lexer.config.DBMS, input)
+		}
+	}
+
+	// ScanInto appends to the tokens it is given
+	lexer := New("SELECT 1")
+	tokens := lexer.ScanInto([]Token{{Type: COMMENT, Value: "-- first"}})
+	assert.Equal(t, []Token{
+		{Type: COMMENT, Value: "-- first"},
+		{Type: IDENT, Value: "SELECT"},
+		{Type: WS, Value: " "},
+		{Type: NUMBER, Value: "1"},
+	}, withoutPositions(tokens))
+}
+
+func TestLexerResetAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector makes the pools drop the lexers and buffers they would reuse")
+	}
+	query := "SELECT * FROM users WHERE id = 1 AND name = 'alice' -- comment"
+	lexer := New(query)
+	tokens := lexer.ScanAll()
+	allocs := testing.AllocsPerRun(100, func() {
+		lexer.Reset(query)
+		tokens = lexer.ScanInto(tokens[:0])
+	})
+	assert.Zero(t, allocs)
+
+	// the obfuscator reuses its lexers and buffers, only the result is allocated
+	obfuscator := NewObfuscator()
+	allocs = testing.AllocsPerRun(100, func() {
+		obfuscator.Obfuscate(query)
+	})
+	assert.Less(t, allocs, 2.0)
+}
+
+// withoutPositions returns a copy of tokens with their offsets and keyword IDs cleared,
+// so that tests can focus on the token types and values.
+func withoutPositions(tokens []Token) []Token {
+	stripped := make([]Token, len(tokens))
+	for i, token := range tokens {
+		stripped[i] = Token{Type: token.Type, Value: token.Value}
+	}
+	return stripped
+}
+
 func ExampleLexer() {
 	query := "SELECT * FROM users WHERE id = 1"
 	lexer := New(query)
 	tokens := lexer.ScanAll()
 	fmt.Println(tokens)
-	// Output: [{6 SELECT} {2  } {9 *} {2  } {6 FROM} {2  } {6 users} {2  } {6 WHERE} {2  } {6 id} {2  } {8 =} {2  } {5 1}]
+	// Output: [{6 SELECT SELECT 0} {2    6} {9  * 7} {2    8} {6 FROM FROM 9} {2    13} {6  users 14} {2    19} {6 WHERE WHERE 20} {2    25} {6  id 26} {2    28} {8  = 29} {2    30} {5  1 31}]
 }
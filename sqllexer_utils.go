@@ -1,8 +1,11 @@
 package sqllexer
 
 import (
+	"bytes"
 	"strings"
+	"sync"
 	"unicode"
+	"unicode/utf8"
 )
 
 type DBMSType string
@@ -18,137 +21,52 @@ const (
 	DBMSOracle DBMSType = "oracle"
 	// DBMSSnowflake is a Snowflake Server
 	DBMSSnowflake DBMSType = "snowflake"
+	// DBMSBigQuery is a Google BigQuery
+	DBMSBigQuery DBMSType = "bigquery"
+	// DBMSClickHouse is a ClickHouse Server
+	DBMSClickHouse DBMSType = "clickhouse"
+	// DBMSSQLite is a SQLite database
+	DBMSSQLite DBMSType = "sqlite"
+	// DBMSTrino is a Trino or Presto Server
+	DBMSTrino DBMSType = "trino"
+	// DBMSDB2 is an IBM Db2 Server
+	DBMSDB2 DBMSType = "db2"
+	// DBMSTeradata is a Teradata Server
+	DBMSTeradata DBMSType = "teradata"
 )
 
-var commands = map[string]bool{
-	"SELECT":        true,
-	"INSERT":        true,
-	"UPDATE":        true,
-	"DELETE":        true,
-	"CREATE":        true,
-	"ALTER":         true,
-	"DROP":          true,
-	"JOIN":          true,
-	"GRANT":         true,
-	"REVOKE":        true,
-	"COMMIT":        true,
-	"BEGIN":         true,
-	"TRUNCATE":      true,
-	"MERGE":         true,
-	"EXECUTE":       true,
-	"EXEC":          true,
-	"EXPLAIN":       true,
-	"STRAIGHT_JOIN": true,
-	"USE":           true,
-	"CLONE":         true,
-}
-
-var tableIndicators = map[string]bool{
-	"FROM":          true,
-	"JOIN":          true,
-	"INTO":          true,
-	"UPDATE":        true,
-	"TABLE":         true,
-	"EXISTS":        true, // Drop Table If Exists
-	"STRAIGHT_JOIN": true, // MySQL
-	"CLONE":         true, // Snowflake
-	"ONLY":          true, // PostgreSQL
-}
-
-var keywords = map[string]bool{
-	"SELECT":     true,
-	"INSERT":     true,
-	"UPDATE":     true,
-	"DELETE":     true,
-	"CREATE":     true,
-	"ALTER":      true,
-	"DROP":       true,
-	"GRANT":      true,
-	"REVOKE":     true,
-	"ADD":        true,
-	"ALL":        true,
-	"AND":        true,
-	"ANY":        true,
-	"AS":         true,
-	"ASC":        true,
-	"BEGIN":      true,
-	"BETWEEN":    true,
-	"BY":         true,
-	"CASE":       true,
-	"CHECK":      true,
-	"COLUMN":     true,
-	"COMMIT":     true,
-	"CONSTRAINT": true,
-	"DATABASE":   true,
-	"DECLARE":    true,
-	"DEFAULT":    true,
-	"DESC":       true,
-	"DISTINCT":   true,
-	"ELSE":       true,
-	"END":        true,
-	"EXEC":       true,
-	"EXISTS":     true,
-	"FOREIGN":    true,
-	"FROM":       true,
-	"GROUP":      true,
-	"HAVING":     true,
-	"IN":         true,
-	"INDEX":      true,
-	"INNER":      true,
-	"INTO":       true,
-	"IS":         true,
-	"JOIN":       true,
-	"KEY":        true,
-	"LEFT":       true,
-	"LIKE":       true,
-	"LIMIT":      true,
-	"NOT":        true,
-	"ON":         true,
-	"OR":         true,
-	"ORDER":      true,
-	"OUTER":      true,
-	"PRIMARY":    true,
-	"PROCEDURE":  true,
-	"REPLACE":    true,
-	"RETURNS":    true,
-	"RIGHT":      true,
-	"ROLLBACK":   true,
-	"ROWNUM":     true,
-	"SET":        true,
-	"SOME":       true,
-	"TABLE":      true,
-	"TOP":        true,
-	"TRUNCATE":   true,
-	"UNION":      true,
-	"UNIQUE":     true,
-	"USE":        true,
-	"VALUES":     true,
-	"VIEW":       true,
-	"WHERE":      true,
-	"CUBE":       true,
-	"ROLLUP":     true,
-	"LITERAL":    true,
-	"WINDOW":     true,
-	"VACCUM":     true,
-	"ANALYZE":    true,
-	"ILIKE":      true,
-	"USING":      true,
-	"ASSERTION":  true,
-	"DOMAIN":     true,
-	"CLUSTER":    true,
-	"COPY":       true,
-	"EXPLAIN":    true,
-	"PLPGSQL":    true,
-	"TRIGGER":    true,
-	"TEMPORARY":  true,
-	"UNLOGGED":   true,
-	"RECURSIVE":  true,
-	"RETURNING":  true,
-	"OFFSET":     true,
-	"OF":         true,
-	"SKIP":       true,
-	"IF":         true,
-	"ONLY":       true,
+// aliasStopWords are words that can follow a table name or an expression without being an alias,
+// and that are not part of the keywords of every DBMS
+var aliasStopWords = map[string]bool{
+	"WITH":        true, // e.g. FROM users WITH (NOLOCK)
+	"NATURAL":     true,
+	"CROSS":       true,
+	"FULL":        true,
+	"LATERAL":     true,
+	"WHEN":        true,
+	"THEN":        true,
+	"FOR":         true, // e.g. FROM jobs FOR UPDATE
+	"FETCH":       true, // e.g. FROM jobs FETCH FIRST 10 ROWS ONLY
+	"EXCEPT":      true,
+	"INTERSECT":   true,
+	"MINUS":       true,
+	"PIVOT":       true,
+	"UNPIVOT":     true,
+	"TABLESAMPLE": true,
+	"FORCE":       true, // e.g. MySQL FROM users FORCE INDEX (idx_name)
+	"USE":         true,
+	"IGNORE":      true,
+}
+
+var comparisonOperators = map[string]bool{
+	"=":  true,
+	"==": true,
+	"!=": true,
+	"<>": true,
+	"<":  true,
+	">":  true,
+	"<=": true,
+	">=": true,
 }
 
 var jsonOperators = map[string]bool{
@@ -169,6 +87,10 @@ func isWhitespace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
+func isBinaryDigit(ch rune) bool {
+	return ch == '0' || ch == '1'
+}
+
 func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
@@ -182,7 +104,11 @@ func isLeadingSign(ch rune) bool {
 }
 
 func isLetter(ch rune) bool {
-	return unicode.IsLetter(ch) || ch == '_'
+	if ch < utf8.RuneSelf {
+		// most of the input is ASCII, which is told apart without the unicode tables
+		return uint32(ch|0x20-'a') < 26 || ch == '_'
+	}
+	return unicode.Is(unicode.Letter, ch)
 }
 
 func isAlphaNumeric(ch rune) bool {
@@ -221,29 +147,16 @@ func isEOF(ch rune) bool {
 	return ch == 0
 }
 
-func isCommand(ident string) bool {
-	_, ok := commands[ident]
+func isAliasStopWord(ident string) bool {
+	_, ok := aliasStopWords[ident]
 	return ok
 }
 
-func isTableIndicator(ident string) bool {
-	_, ok := tableIndicators[ident]
-	return ok
-}
-
-func isSQLKeyword(token *Token) bool {
+func isProcedure(token *keywordToken) bool {
 	if token.Type != IDENT {
 		return false
 	}
-	_, ok := keywords[strings.ToUpper(token.Value)]
-	return ok
-}
-
-func isProcedure(token *Token) bool {
-	if token.Type != IDENT {
-		return false
-	}
-	return strings.ToUpper(token.Value) == "PROCEDURE" || strings.ToUpper(token.Value) == "PROC"
+	return token.Keyword == "PROCEDURE" || token.Keyword == "PROC"
 }
 
 func isBoolean(ident string) bool {
@@ -262,7 +175,51 @@ func isJsonOperator(token *Token) bool {
 	return ok
 }
 
+// isComparison reports whether the token compares the values around it, e.g. = or LIKE.
+func isComparison(token *Token) bool {
+	switch token.Type {
+	case OPERATOR:
+		return comparisonOperators[token.Value]
+	case IDENT:
+		return strings.EqualFold(token.Value, "LIKE") || strings.EqualFold(token.Value, "ILIKE")
+	}
+	return false
+}
+
+// identifierName returns the name of the identifier without its qualifiers and quotes,
+// e.g. status for "t"."status" or [dbo].[users].[status].
+func identifierName(ident string) string {
+	if dot := strings.LastIndexByte(ident, '.'); dot >= 0 {
+		ident = ident[dot+1:]
+	}
+	return strings.Trim(ident, "\"`[]")
+}
+
+// isHexNumber reports whether the number is hexadecimal, e.g. 0x1F.
+func isHexNumber(number string) bool {
+	return len(number) > 1 && number[0] == '0' && (number[1] == 'x' || number[1] == 'X')
+}
+
+// isBinaryNumber reports whether the number is binary, e.g. 0b0101.
+func isBinaryNumber(number string) bool {
+	return len(number) > 1 && number[0] == '0' && (number[1] == 'b' || number[1] == 'B')
+}
+
+// stringLiteralPrefix returns the letters prefixing the string literal, e.g. X for X'1F' or rb for bigquery rb'\d'.
+func stringLiteralPrefix(literal string) string {
+	i := 0
+	for i < len(literal) && isLetter(rune(literal[i])) {
+		i++
+	}
+	return literal[:i]
+}
+
 func replaceDigits(input string, placeholder string) string {
+	if strings.IndexAny(input, "0123456789") < 0 {
+		// nothing to replace, spare the copy
+		return input
+	}
+
 	var builder strings.Builder
 
 	i := 0
@@ -281,30 +238,129 @@ func replaceDigits(input string, placeholder string) string {
 	return builder.String()
 }
 
-var (
-	doubleQuotesReplacer  = strings.NewReplacer("\"", "")
-	backQuotesReplacer    = strings.NewReplacer("`", "")
-	bracketQuotesReplacer = strings.NewReplacer("[", "", "]", "")
-)
+// minInt and maxInt stand in for the min and max builtins, which need Go 1.21.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
 
-func trimQuotes(input string, delim string, closingDelim string) string {
-	var replacer *strings.Replacer
-	switch {
-	// common quote types get an already allocated replacer
-	case delim == closingDelim && delim == "\"":
-		replacer = doubleQuotesReplacer
-	case delim == closingDelim && delim == "`":
-		replacer = backQuotesReplacer
-	case delim == "[" && closingDelim == "]":
-		replacer = bracketQuotesReplacer
-
-	// common case of `delim` and `closingDelim` being the same, gets a simpler replacer
-	case delim == closingDelim:
-		replacer = strings.NewReplacer(delim, "")
-
-	default:
-		replacer = strings.NewReplacer(delim, "", closingDelim, "")
+func maxInt(a, b int) int {
+	if a > b {
+		return a
 	}
+	return b
+}
+
+// unquoteIdentifier removes the quotes of each part of a quoted identifier,
+// e.g. "public"."users" becomes public.users, and public."users" too.
+func unquoteIdentifier(input string) string {
+	return strings.Join(splitQualifiedName(input, false), ".")
+}
 
-	return replacer.Replace(input)
+// unquoteString returns the content of a string literal of the dialect, see Lexer.Unquote.
+// The content is returned without a copy unless it holds escapes.
+func unquoteString(literal string, dialect *Dialect) string {
+	prefix := stringLiteralPrefix(literal)
+	// backslashes do not escape in raw strings, the same way as scanString
+	backslashEscapes := dialect.BackslashEscapes && !strings.ContainsAny(prefix, "rR")
+	literal = literal[len(prefix):]
+	if len(literal) < 2 {
+		return ""
+	}
+	quote := literal[0]
+	quoteLength := 1
+	if dialect.TripleQuotedStrings && len(literal) >= 6 && literal[1] == quote && literal[2] == quote {
+		quoteLength = 3
+	}
+	content := literal[quoteLength : len(literal)-quoteLength]
+	if (!backslashEscapes || strings.IndexByte(content, '\\') < 0) && (quoteLength == 3 || strings.IndexByte(content, quote) < 0) {
+		return content
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(content))
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		switch {
+		case ch == '\\' && backslashEscapes && i+1 < len(content):
+			i++
+			builder.WriteByte(unescape(content[i]))
+		case ch == quote && quoteLength == 1 && i+1 < len(content) && content[i+1] == quote:
+			// a doubled quote is a quote in the string
+			i++
+			builder.WriteByte(quote)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	return builder.String()
+}
+
+// unescape returns the character a backslash followed by ch stands for, e.g. a newline for \n,
+// and ch itself for the characters that are not special, e.g. ' for \'.
+func unescape(ch byte) byte {
+	switch ch {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case 'b':
+		return '\b'
+	case '0':
+		return 0
+	}
+	return ch
+}
+
+// unquoteDollarQuoted returns the content of a dollar quoted string, e.g. abc for $tag$abc$tag$.
+func unquoteDollarQuoted(literal string) string {
+	tagLength := strings.IndexByte(literal[1:], '$') + 2
+	if tagLength < 2 || 2*tagLength > len(literal) {
+		return literal
+	}
+	return literal[tagLength : len(literal)-tagLength]
+}
+
+// bufferPool holds the buffers the obfuscator and the normalizer write their output to.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// maxPooledBufferSize is the capacity above which a buffer is not handed back to the pool,
+// so that a single large query does not keep its memory alive.
+const maxPooledBufferSize = 64 << 10
+
+// getBuffer returns an empty buffer from the pool. It must be handed back with putBuffer.
+func getBuffer() *bytes.Buffer {
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return buffer
+}
+
+func putBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buffer)
+	}
+}
+
+// isPlaceholderOf reports whether the value is the placeholder, or if numbered, the placeholder followed by a number, e.g. $2 for $.
+func isPlaceholderOf(value string, placeholder string, numbered bool) bool {
+	number, ok := strings.CutPrefix(value, placeholder)
+	return ok && (number == "" || numbered && strings.Trim(number, "0123456789") == "")
+}
+
+// isASCII reports whether the text holds ASCII bytes only.
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
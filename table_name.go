@@ -0,0 +1,88 @@
+package sqllexer
+
+import "strings"
+
+// TableName is a table reference split into its parts, with the quotes removed.
+// The parts are assigned from the right, so Name is always set, and Schema, Catalog and Server
+// are only set when the reference is qualified enough, e.g. db.schema.table has no Server.
+type TableName struct {
+	Server   string `json:"server,omitempty"`  // the linked server, SQL Server only, e.g. srv in srv.db.dbo.users
+	Catalog  string `json:"catalog,omitempty"` // the database, e.g. db in db.dbo.users
+	Schema   string `json:"schema,omitempty"`  // the schema, e.g. dbo in dbo.users, or the database of a MySQL db.users
+	Name     string `json:"name"`              // the table name
+	Original string `json:"original"`          // the reference as written in the query, quotes included
+}
+
+// ParseTableName splits a possibly qualified and quoted table reference into its parts,
+// e.g. "Sales"."Order.Details" has the schema Sales and the name Order.Details.
+// Parts can be quoted with double quotes, backticks (MySQL) or brackets (SQL Server).
+// SQL Server references can have up to four parts (server.catalog.schema.name),
+// the other DBMS have up to three (catalog.schema.name), any extra part being kept in the leftmost one.
+// The number of parts of a registered dialect is set by Dialect.MaxNameParts.
+// BigQuery quotes whole paths, the project of `project.dataset.table` is reported as the catalog
+// and the dataset as the schema.
+// MySQL and ClickHouse have no schemas within a database, the database of db.users is reported as the schema.
+func ParseTableName(name string, dbms DBMSType) TableName {
+	dialect := dialectFor(dbms)
+	parts := splitQualifiedName(name, dialect.SplitQuotedNames)
+	tableName := TableName{Original: name}
+
+	n := len(parts)
+	tableName.Name = parts[n-1]
+	if n > 1 {
+		tableName.Schema = parts[n-2]
+	}
+	switch {
+	case n > 3 && dialect.maxNameParts() > 3:
+		tableName.Catalog = parts[n-3]
+		tableName.Server = strings.Join(parts[:n-3], ".")
+	case n > 2:
+		tableName.Catalog = strings.Join(parts[:n-2], ".")
+	}
+	return tableName
+}
+
+// splitQualifiedName splits a qualified name on the dots that are not quoted, and removes the quotes of each part,
+// e.g. "Sales"."Order.Details" becomes [Sales Order.Details].
+// If splitQuoted is true, the quoted dots split the name too, e.g. `project.dataset`.table becomes [project dataset table].
+func splitQualifiedName(name string, splitQuoted bool) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(name); {
+		switch ch := name[i]; ch {
+		case '"', '`', '[':
+			closingDelimiter := ch
+			if ch == '[' {
+				closingDelimiter = ']'
+			}
+			end := strings.IndexByte(name[i+1:], closingDelimiter)
+			if end < 0 {
+				// unterminated quoted part, keep the rest as is
+				part.WriteString(name[i+1:])
+				i = len(name)
+				continue
+			}
+			quoted := name[i+1 : i+1+end]
+			for splitQuoted {
+				dot := strings.IndexByte(quoted, '.')
+				if dot < 0 {
+					break
+				}
+				part.WriteString(quoted[:dot])
+				parts = append(parts, part.String())
+				part.Reset()
+				quoted = quoted[dot+1:]
+			}
+			part.WriteString(quoted)
+			i += end + 2
+		case '.':
+			parts = append(parts, part.String())
+			part.Reset()
+			i++
+		default:
+			part.WriteByte(ch)
+			i++
+		}
+	}
+	return append(parts, part.String())
+}
@@ -0,0 +1,76 @@
+package sqllexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTableName(t *testing.T) {
+	tests := []struct {
+		input    string
+		dbms     DBMSType
+		expected TableName
+	}{
+		{
+			input:    "users",
+			expected: TableName{Name: "users", Original: "users"},
+		},
+		{
+			input:    "public.users",
+			dbms:     DBMSPostgres,
+			expected: TableName{Schema: "public", Name: "users", Original: "public.users"},
+		},
+		{
+			input:    `"Sales"."Order.Details"`,
+			dbms:     DBMSPostgres,
+			expected: TableName{Schema: "Sales", Name: "Order.Details", Original: `"Sales"."Order.Details"`},
+		},
+		{
+			input:    `public."users"`,
+			dbms:     DBMSPostgres,
+			expected: TableName{Schema: "public", Name: "users", Original: `public."users"`},
+		},
+		{
+			input:    "analytics.public.events",
+			dbms:     DBMSSnowflake,
+			expected: TableName{Catalog: "analytics", Schema: "public", Name: "events", Original: "analytics.public.events"},
+		},
+		{
+			input:    "srv.[my db].dbo.[users]",
+			dbms:     DBMSSQLServer,
+			expected: TableName{Server: "srv", Catalog: "my db", Schema: "dbo", Name: "users", Original: "srv.[my db].dbo.[users]"},
+		},
+		{
+			input:    "`my-project.analytics.events`",
+			dbms:     DBMSBigQuery,
+			expected: TableName{Catalog: "my-project", Schema: "analytics", Name: "events", Original: "`my-project.analytics.events`"},
+		},
+		{
+			input:    "`my-project`.analytics.events",
+			dbms:     DBMSBigQuery,
+			expected: TableName{Catalog: "my-project", Schema: "analytics", Name: "events", Original: "`my-project`.analytics.events"},
+		},
+		{
+			input:    "[db]..[users]",
+			dbms:     DBMSSQLServer,
+			expected: TableName{Catalog: "db", Name: "users", Original: "[db]..[users]"},
+		},
+		{
+			input:    "shop.`order`",
+			dbms:     DBMSMySQL,
+			expected: TableName{Schema: "shop", Name: "order", Original: "shop.`order`"},
+		},
+		{
+			input:    "a.b.c.d",
+			dbms:     DBMSOracle,
+			expected: TableName{Catalog: "a.b", Schema: "c", Name: "d", Original: "a.b.c.d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			assert.Equal(t, test.expected, ParseTableName(test.input, test.dbms))
+		})
+	}
+}
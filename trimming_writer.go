@@ -0,0 +1,80 @@
+package sqllexer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// trimmingWriter writes the output of the obfuscator and the normalizer to an io.Writer as it is produced,
+// trimmed the same way as the strings returned by Obfuscate and Normalize: the leading and trailing whitespace,
+// and the trailing statement terminator when one is set, are dropped.
+// The trailing part that might still be trimmed is held back until something else is written after it.
+type trimmingWriter struct {
+	w          *bufio.Writer
+	terminator string // the trailing statement terminator that is dropped, e.g. ;, none if empty, see WithKeepTrailingSemicolon
+	started    bool   // whether anything else than whitespace was written
+	pending    []byte // the trailing part of the output that might still be trimmed
+	err        error  // the first error returned by the writer
+}
+
+func newTrimmingWriter(w io.Writer, terminator string) *trimmingWriter {
+	return &trimmingWriter{w: bufio.NewWriter(w), terminator: terminator}
+}
+
+// WriteString implements io.StringWriter, it never fails and the error of the writer is reported by Close.
+func (t *trimmingWriter) WriteString(s string) (int, error) {
+	n := len(s)
+	if !t.started {
+		if s = strings.TrimLeftFunc(s, unicode.IsSpace); s == "" {
+			return n, nil
+		}
+		t.started = true
+	}
+	if cut := t.trimmableSuffix(s); cut > 0 {
+		// s ends the part of the output that is never trimmed, only its own suffix is held back
+		t.write(string(t.pending))
+		t.write(s[:cut])
+		t.pending = append(t.pending[:0], s[cut:]...)
+		return n, nil
+	}
+	t.pending = append(t.pending, s...)
+	if cut := t.trimmableSuffix(string(t.pending)); cut > 0 {
+		// e.g. the second semicolon of ; ; keeps the first one from being trimmed
+		t.write(string(t.pending[:cut]))
+		t.pending = append(t.pending[:0], t.pending[cut:]...)
+	}
+	return n, nil
+}
+
+// Close writes the trimmed trailing part of the output, flushes the writer and returns its first error.
+func (t *trimmingWriter) Close() error {
+	tail := string(t.pending)
+	if t.terminator != "" {
+		tail = strings.TrimSuffix(tail, t.terminator)
+	}
+	t.write(strings.TrimRightFunc(tail, unicode.IsSpace))
+	t.pending = t.pending[:0]
+	if t.err == nil {
+		t.err = t.w.Flush()
+	}
+	return t.err
+}
+
+// trimmableSuffix returns the index where the suffix of s that might be trimmed starts,
+// i.e. the trailing whitespace, along with the terminator before it and the whitespace before the terminator.
+func (t *trimmingWriter) trimmableSuffix(s string) int {
+	cut := len(strings.TrimRightFunc(s, unicode.IsSpace))
+	if t.terminator != "" && strings.HasSuffix(s[:cut], t.terminator) {
+		cut = len(strings.TrimRightFunc(s[:cut-len(t.terminator)], unicode.IsSpace))
+	}
+	return cut
+}
+
+func (t *trimmingWriter) write(s string) {
+	if t.err != nil || s == "" {
+		return
+	}
+	_, t.err = t.w.WriteString(s)
+}
@@ -0,0 +1,61 @@
+package sqllexer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimmingWriter(t *testing.T) {
+	tests := [][]string{
+		{" ", "SELECT", " ", "1"},
+		{" ", "SELECT", " ", "1", ";"},
+		{" ", "SELECT", " ", "1", ";", " "},
+		{" ", "SELECT", " ", "1", " ", ";"},
+		{"SELECT 1", ";", ";"},
+		{"SELECT 1", ";", " ", ";"},
+		{"SELECT 1 ; ", "\n"},
+		{"BEGIN", ";", " ", "END", ";"},
+		{"SELECT 1", " ", "@"},
+		{"SELECT 1", " ", "@", " ", "@", " "},
+		{"  ", "\t", ";"},
+		{";"},
+		{" ", " x ", " "},
+		{},
+	}
+
+	for _, writes := range tests {
+		for _, terminator := range []string{";", "@", ""} {
+			input := strings.Join(writes, "")
+			expected := input
+			if terminator != "" {
+				expected = strings.TrimSuffix(expected, terminator)
+			}
+			expected = strings.TrimSpace(expected)
+
+			var output strings.Builder
+			writer := newTrimmingWriter(&output, terminator)
+			for _, s := range writes {
+				writer.WriteString(s)
+			}
+			assert.NoError(t, writer.Close())
+			assert.Equal(t, expected, output.String(), "%q", input)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestObfuscateToWriteError(t *testing.T) {
+	err := NewObfuscator().ObfuscateTo(failingWriter{}, strings.NewReader("SELECT * FROM users WHERE id = 1"))
+	assert.EqualError(t, err, "disk full")
+
+	_, err = NewNormalizer().NormalizeTo(failingWriter{}, strings.NewReader("SELECT * FROM users WHERE id = ?"))
+	assert.EqualError(t, err, "disk full")
+}